@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// authGatherer checks an account's token before gathering the wrapped Gatherer.
+// If the API rejects the token the wrapped Gatherer isn't gathered at all,
+// instead of every collector failing with the same error.
+type authGatherer struct {
+	logger   log.Logger
+	client   *godo.Client
+	timeout  time.Duration
+	gatherer prometheus.Gatherer
+
+	registry   *prometheus.Registry
+	tokenValid prometheus.Gauge
+}
+
+func newAuthGatherer(logger log.Logger, client *godo.Client, timeout time.Duration, gatherer prometheus.Gatherer) authGatherer {
+	g := authGatherer{
+		logger:   logger,
+		client:   client,
+		timeout:  timeout,
+		gatherer: gatherer,
+
+		registry: prometheus.NewRegistry(),
+		tokenValid: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "digitalocean_exporter_token_valid",
+			Help: "If 1 the token was accepted by the API during the last scrape, 0 if it was rejected",
+		}),
+	}
+	g.registry.MustRegister(g.tokenValid)
+	return g
+}
+
+// Gather implements prometheus.Gatherer.
+func (g authGatherer) Gather() ([]*dto.MetricFamily, error) {
+	if !g.validToken() {
+		g.tokenValid.Set(0)
+		return g.registry.Gather()
+	}
+
+	g.tokenValid.Set(1)
+	return prometheus.Gatherers{g.registry, g.gatherer}.Gather()
+}
+
+// validToken reports whether the API accepts the token.
+// Any other error than 401 or 403 is left to the collectors.
+func (g authGatherer) validToken() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	_, resp, err := g.client.Account.Get(ctx)
+	if err == nil || resp == nil {
+		return true
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		level.Warn(g.logger).Log("msg", "token was rejected, skipping collectors", "status", resp.StatusCode)
+		return false
+	}
+	return true
+}
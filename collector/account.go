@@ -2,6 +2,8 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/digitalocean/godo"
@@ -10,28 +12,49 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("account", func(c Config) prometheus.Collector {
+		return NewAccountCollector(c)
+	})
+}
+
 // AccountCollector collects metrics about the account.
 type AccountCollector struct {
 	logger  log.Logger
 	client  *godo.Client
 	timeout time.Duration
+	perPage int
+	cache   *ScrapeCache
+	usage   bool
+	*lastSuccess
 
 	DropletLimit    *prometheus.Desc
 	FloatingIPLimit *prometheus.Desc
 	EmailVerified   *prometheus.Desc
 	Active          *prometheus.Desc
+	Team            *prometheus.Desc
+
+	DropletLimitUsage    *prometheus.Desc
+	FloatingIPLimitUsage *prometheus.Desc
+	VolumeLimitUsage     *prometheus.Desc
 }
 
-// NewAccountCollector returns a new AccountCollector.
-func NewAccountCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *AccountCollector {
+// NewAccountCollector returns a new AccountCollector built from the Config.
+// With AccountLimitUsage the droplets, floating ips and volumes are got from the cache,
+// to expose the ratio of each limit that they use.
+func NewAccountCollector(c Config) *AccountCollector {
 	return &AccountCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		cache:       c.Cache,
+		usage:       c.AccountLimitUsage,
+		lastSuccess: newLastSuccess("account"),
 
 		DropletLimit: prometheus.NewDesc(
 			"digitalocean_account_droplet_limit",
-			"The maximum number of droplet you can use",
+			"The maximum number of droplets you can use",
 			nil, nil,
 		),
 		FloatingIPLimit: prometheus.NewDesc(
@@ -41,17 +64,49 @@ func NewAccountCollector(logger log.Logger, client *godo.Client, timeout time.Du
 		),
 		EmailVerified: prometheus.NewDesc(
 			"digitalocean_account_verified",
-			"1 if your email address was verified",
+			"If 1 your email address was verified, 0 otherwise",
 			nil, nil,
 		),
 		Active: prometheus.NewDesc(
 			"digitalocean_account_active",
-			"The status of your account",
+			"If 1 your account is active, 0 otherwise",
+			nil, nil,
+		),
+		Team: prometheus.NewDesc(
+			"digitalocean_account_team",
+			"A metric with a constant '1' value labeled by the uuid and name of the team the token belongs to",
+			[]string{"uuid", "name"}, nil,
+		),
+
+		DropletLimitUsage: prometheus.NewDesc(
+			"digitalocean_droplet_limit_usage_ratio",
+			"Ratio of the droplet limit used by the account's droplets",
+			nil, nil,
+		),
+		FloatingIPLimitUsage: prometheus.NewDesc(
+			"digitalocean_floating_ip_limit_usage_ratio",
+			"Ratio of the floating ip limit used by the account's floating ips",
+			nil, nil,
+		),
+		VolumeLimitUsage: prometheus.NewDesc(
+			"digitalocean_volume_limit_usage_ratio",
+			"Ratio of the volume limit used by the account's volumes",
 			nil, nil,
 		),
 	}
 }
 
+// accountWithLimits is an account with the limits and team godo doesn't decode.
+type accountWithLimits struct {
+	godo.Account
+	VolumeLimit int `json:"volume_limit,omitempty"`
+	// Team is only set for tokens of a team.
+	Team *struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"team,omitempty"`
+}
+
 // Describe sends the super-set of all possible descriptors of metrics
 // collected by this Collector.
 func (c *AccountCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -59,21 +114,39 @@ func (c *AccountCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.FloatingIPLimit
 	ch <- c.EmailVerified
 	ch <- c.Active
+	ch <- c.Team
+	ch <- c.DropletLimitUsage
+	ch <- c.FloatingIPLimitUsage
+	ch <- c.VolumeLimitUsage
+	ch <- c.lastSuccess.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *AccountCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
-	acc, _, err := c.client.Account.Get(ctx)
+	acc, err := c.getAccount(ctx)
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't get account",
 			"err", err,
 		)
+		c.lastSuccess.collect(ch, false)
 		return
 	}
 
+	var usageErr error
+	if c.usage {
+		usageErr = c.collectLimitUsage(ctx, ch, acc)
+		if usageErr != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list resources for limit usage",
+				"err", usageErr,
+			)
+		}
+	}
+	c.lastSuccess.collect(ch, usageErr == nil)
+
 	ch <- prometheus.MustNewConstMetric(
 		c.DropletLimit,
 		prometheus.GaugeValue,
@@ -104,4 +177,70 @@ func (c *AccountCollector) Collect(ch chan<- prometheus.Metric) {
 		prometheus.GaugeValue,
 		status,
 	)
+
+	if acc.Team != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.Team,
+			prometheus.GaugeValue,
+			1.0,
+			acc.Team.UUID, acc.Team.Name,
+		)
+	}
+}
+
+// getAccount gets the account with all its limits.
+func (c *AccountCollector) getAccount(ctx context.Context) (*accountWithLimits, error) {
+	req, err := c.client.NewRequest(ctx, http.MethodGet, "v2/account", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	root := struct {
+		Account *accountWithLimits `json:"account"`
+	}{}
+	if _, err := c.client.Do(ctx, req, &root); err != nil {
+		return nil, err
+	}
+	if root.Account == nil {
+		return nil, fmt.Errorf("no account in response")
+	}
+	return root.Account, nil
+}
+
+// collectLimitUsage sends the ratio of each of the account's limits used by its resources.
+// Limits that are 0 are unknown, so their ratio isn't sent.
+func (c *AccountCollector) collectLimitUsage(ctx context.Context, ch chan<- prometheus.Metric, acc *accountWithLimits) error {
+	// The limits are for the whole account, so all droplets count, not only the configured ones.
+	droplets, err := c.cache.allDroplets(ctx)
+	if err != nil {
+		return err
+	}
+	floatingIPs, err := c.cache.floatingIPs(ctx)
+	if err != nil {
+		return err
+	}
+	volumes, err := c.cache.volumes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, usage := range []struct {
+		desc  *prometheus.Desc
+		count int
+		limit int
+	}{
+		{c.DropletLimitUsage, len(droplets), acc.DropletLimit},
+		{c.FloatingIPLimitUsage, len(floatingIPs), acc.FloatingIPLimit},
+		{c.VolumeLimitUsage, len(volumes), acc.VolumeLimit},
+	} {
+		if usage.limit <= 0 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			usage.desc,
+			prometheus.GaugeValue,
+			float64(usage.count)/float64(usage.limit),
+		)
+	}
+	return nil
 }
@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestAccountCollectorTeam(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/account": `{"account":{"droplet_limit":25,"floating_ip_limit":5,"volume_limit":100,"email":"ops@example.com","uuid":"u1","email_verified":true,"status":"active",
+			"team":{"uuid":"t1","name":"Platform"}}}`,
+	})
+	mfs := gather(t, newTestCollector(t, "account", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 1, "digitalocean_account_team", "uuid=t1", "name=Platform")
+	assertMetric(t, mfs, 25, "digitalocean_account_droplet_limit")
+	assertMetric(t, mfs, 1, "digitalocean_account_active")
+}
+
+func TestAccountCollectorWithoutTeam(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/account": `{"account":{"droplet_limit":25,"email":"me@example.com","uuid":"u1","status":"active"}}`,
+	})
+	mfs := gather(t, newTestCollector(t, "account", testConfig(api.client(t))))
+
+	for _, mf := range mfs {
+		if mf.GetName() == "digitalocean_account_team" {
+			t.Errorf("digitalocean_account_team is collected for a personal account")
+		}
+	}
+}
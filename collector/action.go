@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// listActionsSince lists all actions of the account started since then.
+// The API returns the newest actions first, so paging stops at the first older action.
+func listActionsSince(ctx context.Context, client *godo.Client, perPage int, since time.Time) ([]godo.Action, error) {
+	var actions []godo.Action
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.Actions.List(ctx, opt)
+		if err != nil {
+			return resp, err
+		}
+
+		for _, action := range page {
+			if action.StartedAt != nil && action.StartedAt.Before(since) {
+				return resp, errStopPaging
+			}
+			actions = append(actions, action)
+		}
+		return resp, nil
+	})
+	return actions, err
+}
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("action", func(c Config) prometheus.Collector {
+		return NewActionFailureCollector(c)
+	})
+}
+
+// failedActionWindow limits how far back the actions are read for failures.
+// It only has to be longer than the scrape interval, as failures are remembered.
+const failedActionWindow = time.Hour
+
+// ActionFailureCollector counts the account's actions that errored, like a
+// load balancer that couldn't be provisioned or a floating ip that couldn't be assigned.
+type ActionFailureCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	*lastSuccess
+	pages *pageCounter
+
+	mu sync.Mutex
+	// seen are the IDs of the errored actions already counted, as long as they're listed.
+	seen   map[int]bool
+	failed map[string]uint64
+
+	Failed *prometheus.Desc
+}
+
+// NewActionFailureCollector returns a new ActionFailureCollector built from the Config.
+func NewActionFailureCollector(c Config) *ActionFailureCollector {
+	return &ActionFailureCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		lastSuccess: newLastSuccess("action"),
+		pages:       newPageCounter("action"),
+		seen:        map[int]bool{},
+		failed:      map[string]uint64{},
+
+		Failed: prometheus.NewDesc(
+			"digitalocean_resource_action_failed_total",
+			"Number of errored actions by the type of resource they were taken on",
+			[]string{"resource_type"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *ActionFailureCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Failed
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *ActionFailureCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	since := time.Now().Add(-failedActionWindow)
+	actions, err := listActionsSince(ctx, c.client, c.perPage, since)
+	c.lastSuccess.collect(ch, err == nil)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list actions",
+			"err", err,
+		)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Without the actions the previous counts are still sent, as counters.
+	if err == nil {
+		c.count(actions)
+	}
+	for resourceType, failed := range c.failed {
+		ch <- prometheus.MustNewConstMetric(
+			c.Failed,
+			prometheus.CounterValue,
+			float64(failed),
+			resourceType,
+		)
+	}
+}
+
+// count adds the errored actions that weren't seen before.
+// Actions that aren't listed anymore are forgotten.
+func (c *ActionFailureCollector) count(actions []godo.Action) {
+	seen := map[int]bool{}
+	for _, action := range actions {
+		if action.Status != "errored" {
+			continue
+		}
+		if !c.seen[action.ID] {
+			c.failed[action.ResourceType]++
+		}
+		seen[action.ID] = true
+	}
+	c.seen = seen
+}
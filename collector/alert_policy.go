@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("alert_policy", func(c Config) prometheus.Collector {
+		return NewAlertPolicyCollector(c)
+	})
+}
+
+// alertPolicy is a monitoring alert policy, which the vendored godo doesn't know about.
+// The API doesn't tell whether a policy is currently firing, only how it's configured.
+type alertPolicy struct {
+	UUID string `json:"uuid"`
+	// Type is the metric the policy alerts on, like v1/insights/droplet/cpu.
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Compare is GreaterThan or LessThan.
+	Compare string  `json:"compare"`
+	Value   float64 `json:"value"`
+	// Window is how long the comparison must hold, like 5m.
+	Window  string `json:"window"`
+	Enabled bool   `json:"enabled"`
+}
+
+// AlertPolicyCollector collects metrics about the monitoring alert policies of the account.
+type AlertPolicyCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	*lastSuccess
+	pages *pageCounter
+
+	Enabled   *prometheus.Desc
+	Threshold *prometheus.Desc
+}
+
+// NewAlertPolicyCollector returns a new AlertPolicyCollector built from the Config.
+func NewAlertPolicyCollector(c Config) *AlertPolicyCollector {
+	return &AlertPolicyCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		lastSuccess: newLastSuccess("alert_policy"),
+		pages:       newPageCounter("alert_policy"),
+
+		Enabled: prometheus.NewDesc(
+			"digitalocean_alert_policy_enabled",
+			"If 1 the alert policy is enabled, 0 otherwise",
+			[]string{"uuid", "type", "description"}, nil,
+		),
+		Threshold: prometheus.NewDesc(
+			"digitalocean_alert_policy_threshold",
+			"Value the alert policy compares its metric to",
+			[]string{"uuid", "compare", "window"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *AlertPolicyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Enabled
+	ch <- c.Threshold
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *AlertPolicyCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	policies, err := listAlertPolicies(ctx, c.client, c.perPage)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list alert policies",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	for _, policy := range policies {
+		var enabled float64
+		if policy.Enabled {
+			enabled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Enabled,
+			prometheus.GaugeValue,
+			enabled,
+			policy.UUID, policy.Type, policy.Description,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.Threshold,
+			prometheus.GaugeValue,
+			policy.Value,
+			policy.UUID, policy.Compare, policy.Window,
+		)
+	}
+
+	c.lastSuccess.collect(ch, true)
+}
+
+func listAlertPolicies(ctx context.Context, client *godo.Client, perPage int) ([]alertPolicy, error) {
+	var policies []alertPolicy
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Policies []alertPolicy `json:"policies"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/monitoring/alerts", opt, &root)
+		policies = append(policies, root.Policies...)
+		return resp, err
+	})
+	return policies, err
+}
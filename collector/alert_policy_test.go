@@ -0,0 +1,20 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestAlertPolicyCollector(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/monitoring/alerts": `{"policies":[
+			{"uuid":"p1","type":"v1/insights/droplet/cpu","description":"CPU is running high","compare":"GreaterThan","value":80,"window":"5m","enabled":true},
+			{"uuid":"p2","type":"v1/insights/droplet/memory_utilization_percent","description":"Memory","compare":"GreaterThan","value":90,"window":"10m","enabled":false}
+		],"links":{}}`,
+	})
+	mfs := gather(t, newTestCollector(t, "alert_policy", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 1, "digitalocean_alert_policy_enabled", "uuid=p1", "type=v1/insights/droplet/cpu", "description=CPU is running high")
+	assertMetric(t, mfs, 0, "digitalocean_alert_policy_enabled", "uuid=p2", "type=v1/insights/droplet/memory_utilization_percent", "description=Memory")
+	assertMetric(t, mfs, 80, "digitalocean_alert_policy_threshold", "uuid=p1", "compare=GreaterThan", "window=5m")
+	assertMetric(t, mfs, 90, "digitalocean_alert_policy_threshold", "uuid=p2", "compare=GreaterThan", "window=10m")
+}
@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("app", func(c Config) prometheus.Collector {
+		return NewAppCollector(c)
+	})
+}
+
+// apiApp is an App Platform app, which the vendored godo doesn't know about.
+type apiApp struct {
+	ID     string `json:"id"`
+	Tier   string `json:"tier_slug"`
+	Region *struct {
+		Slug string `json:"slug"`
+	} `json:"region"`
+	Spec struct {
+		Name        string     `json:"name"`
+		Services    []struct{} `json:"services"`
+		StaticSites []struct{} `json:"static_sites"`
+		Workers     []struct{} `json:"workers"`
+		Jobs        []struct{} `json:"jobs"`
+		Functions   []struct{} `json:"functions"`
+	} `json:"spec"`
+	ActiveDeployment     *appDeployment `json:"active_deployment"`
+	InProgressDeployment *appDeployment `json:"in_progress_deployment"`
+}
+
+type appDeployment struct {
+	ID string `json:"id"`
+	// Phase is one of PENDING_BUILD, BUILDING, PENDING_DEPLOY, DEPLOYING, ACTIVE,
+	// SUPERSEDED, ERROR or CANCELED.
+	Phase     string    `json:"phase"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AppCollector collects metrics about the App Platform apps of the account.
+type AppCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	regions []string
+	*lastSuccess
+	pages *pageCounter
+
+	Info              *prometheus.Desc
+	Components        *prometheus.Desc
+	DeploymentPhase   *prometheus.Desc
+	DeploymentCreated *prometheus.Desc
+}
+
+// NewAppCollector returns a new AppCollector built from the Config.
+func NewAppCollector(c Config) *AppCollector {
+	labels := []string{"id", "name", "region"}
+
+	return &AppCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		lastSuccess: newLastSuccess("app"),
+		pages:       newPageCounter("app"),
+
+		Info: prometheus.NewDesc(
+			"digitalocean_app_info",
+			"A metric with a constant '1' value labeled by the app's tier",
+			append(labels, "tier"), nil,
+		),
+		Components: prometheus.NewDesc(
+			"digitalocean_app_components",
+			"Number of the app's components by their type, like service or worker",
+			append(labels, "type"), nil,
+		),
+		DeploymentPhase: prometheus.NewDesc(
+			"digitalocean_app_deployment_phase",
+			"A metric with a constant '1' value labeled by the phase of the app's active or in progress deployment",
+			append(labels, "deployment", "phase"), nil,
+		),
+		DeploymentCreated: prometheus.NewDesc(
+			"digitalocean_app_deployment_created_timestamp_seconds",
+			"Unix timestamp of the creation of the app's active or in progress deployment",
+			append(labels, "deployment"), nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *AppCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Info
+	ch <- c.Components
+	ch <- c.DeploymentPhase
+	ch <- c.DeploymentCreated
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *AppCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	apps, err := listApps(ctx, c.client, c.perPage)
+	c.lastSuccess.collect(ch, err == nil)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list apps",
+			"err", err,
+		)
+		return
+	}
+
+	for _, app := range apps {
+		var region string
+		if app.Region != nil {
+			region = app.Region.Slug
+		}
+		if !inRegionSlugs(c.regions, region) {
+			continue
+		}
+		labels := []string{app.ID, app.Spec.Name, region}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Info,
+			prometheus.GaugeValue,
+			1.0,
+			append(labels, app.Tier)...,
+		)
+
+		components := []struct {
+			kind  string
+			count int
+		}{
+			{"service", len(app.Spec.Services)},
+			{"static_site", len(app.Spec.StaticSites)},
+			{"worker", len(app.Spec.Workers)},
+			{"job", len(app.Spec.Jobs)},
+			{"function", len(app.Spec.Functions)},
+		}
+		for _, component := range components {
+			ch <- prometheus.MustNewConstMetric(
+				c.Components,
+				prometheus.GaugeValue,
+				float64(component.count),
+				append(labels, component.kind)...,
+			)
+		}
+
+		// An app has no active deployment before its first one succeeded,
+		// and only an in progress one while it's being deployed.
+		deployments := []struct {
+			kind       string
+			deployment *appDeployment
+		}{
+			{"active", app.ActiveDeployment},
+			{"in_progress", app.InProgressDeployment},
+		}
+		for _, d := range deployments {
+			if d.deployment == nil || d.deployment.ID == "" {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.DeploymentPhase,
+				prometheus.GaugeValue,
+				1.0,
+				append(labels, d.kind, d.deployment.Phase)...,
+			)
+			if !d.deployment.CreatedAt.IsZero() {
+				ch <- prometheus.MustNewConstMetric(
+					c.DeploymentCreated,
+					prometheus.GaugeValue,
+					float64(d.deployment.CreatedAt.Unix()),
+					append(labels, d.kind)...,
+				)
+			}
+		}
+	}
+}
+
+func listApps(ctx context.Context, client *godo.Client, perPage int) ([]apiApp, error) {
+	var apps []apiApp
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Apps []apiApp `json:"apps"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/apps", opt, &root)
+		apps = append(apps, root.Apps...)
+		return resp, err
+	})
+	return apps, err
+}
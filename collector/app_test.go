@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"testing"
+)
+
+var appFixtures = map[string]string{
+	"/v2/apps": `{"apps":[
+		{"id":"a1","tier_slug":"basic","region":{"slug":"ams"},
+		 "spec":{"name":"shop","services":[{"name":"web"},{"name":"api"}],"workers":[{"name":"queue"}]},
+		 "active_deployment":{"id":"dep1","phase":"ACTIVE","created_at":"2020-01-02T03:04:05Z"},
+		 "in_progress_deployment":{"id":"dep2","phase":"BUILDING","created_at":"2021-01-02T03:04:05Z"}},
+		{"id":"a2","tier_slug":"starter","region":{"slug":"nyc"},
+		 "spec":{"name":"blog","static_sites":[{"name":"site"}]}}
+	],"links":{}}`,
+}
+
+func TestAppCollector(t *testing.T) {
+	api := newTestAPI(t, appFixtures)
+	mfs := gather(t, newTestCollector(t, "app", testConfig(api.client(t))))
+
+	shop := []string{"id=a1", "name=shop", "region=ams"}
+	blog := []string{"id=a2", "name=blog", "region=nyc"}
+
+	assertMetric(t, mfs, 1, "digitalocean_app_info", append(shop, "tier=basic")...)
+	assertMetric(t, mfs, 2, "digitalocean_app_components", append(shop, "type=service")...)
+	assertMetric(t, mfs, 1, "digitalocean_app_components", append(shop, "type=worker")...)
+	assertMetric(t, mfs, 0, "digitalocean_app_components", append(shop, "type=static_site")...)
+	assertMetric(t, mfs, 1, "digitalocean_app_components", append(blog, "type=static_site")...)
+	assertMetric(t, mfs, 1, "digitalocean_app_deployment_phase", append(shop, "deployment=active", "phase=ACTIVE")...)
+	assertMetric(t, mfs, 1, "digitalocean_app_deployment_phase", append(shop, "deployment=in_progress", "phase=BUILDING")...)
+	assertMetric(t, mfs, 1609556645, "digitalocean_app_deployment_created_timestamp_seconds", append(shop, "deployment=in_progress")...)
+	assertNoMetric(t, mfs, "digitalocean_app_deployment_created_timestamp_seconds", append(blog, "deployment=active")...)
+}
+
+func TestAppCollectorRegions(t *testing.T) {
+	api := newTestAPI(t, appFixtures)
+	c := testConfig(api.client(t))
+	c.Regions = []string{"nyc"}
+	mfs := gather(t, newTestCollector(t, "app", c))
+
+	assertNoMetric(t, mfs, "digitalocean_app_info", "id=a1", "name=shop", "region=ams", "tier=basic")
+	assertMetric(t, mfs, 1, "digitalocean_app_info", "id=a2", "name=blog", "region=nyc", "tier=starter")
+}
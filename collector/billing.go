@@ -0,0 +1,230 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("billing", func(c Config) prometheus.Collector {
+		if !c.Billing {
+			return nil
+		}
+		return NewBillingCollector(c)
+	})
+}
+
+// billingBalance is the balance of the account, which the vendored godo doesn't know about.
+// The amounts are strings of dollars, like "23.44".
+type billingBalance struct {
+	MonthToDateUsage string `json:"month_to_date_usage"`
+	AccountBalance   string `json:"account_balance"`
+}
+
+// invoiceItem is an item of an invoice, which the vendored godo doesn't know about.
+type invoiceItem struct {
+	// Product is what's billed, like Spaces Subscription.
+	Product     string `json:"product"`
+	Description string `json:"description"`
+	// Amount is a string of dollars, like "5.00".
+	Amount string `json:"amount"`
+}
+
+// BillingCollector collects metrics about the billing of the account.
+type BillingCollector struct {
+	logger    log.Logger
+	client    *godo.Client
+	timeout   time.Duration
+	perPage   int
+	spaces    *regexp.Regexp
+	bandwidth *regexp.Regexp
+	*lastSuccess
+	pages *pageCounter
+
+	MonthToDateUsage *prometheus.Desc
+	AccountBalance   *prometheus.Desc
+	SpacesCost       *prometheus.Desc
+	BandwidthCost    *prometheus.Desc
+}
+
+// NewBillingCollector returns a new BillingCollector built from the Config.
+// The items of the current month's invoice preview are matched by their product and
+// description against BillingSpacesMatch and BillingBandwidthMatch.
+func NewBillingCollector(c Config) *BillingCollector {
+	return &BillingCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		spaces:      c.BillingSpacesMatch,
+		bandwidth:   c.BillingBandwidthMatch,
+		lastSuccess: newLastSuccess("billing"),
+		pages:       newPageCounter("billing"),
+
+		MonthToDateUsage: prometheus.NewDesc(
+			"digitalocean_billing_month_to_date_usage_usd",
+			"Amount in dollars used by the account in the current month",
+			nil, nil,
+		),
+		AccountBalance: prometheus.NewDesc(
+			"digitalocean_billing_account_balance_usd",
+			"Balance in dollars of the account, without the current month's usage",
+			nil, nil,
+		),
+		SpacesCost: prometheus.NewDesc(
+			"digitalocean_spaces_cost_usd",
+			"Amount in dollars of the current month's invoice items matching the Spaces pattern",
+			nil, nil,
+		),
+		BandwidthCost: prometheus.NewDesc(
+			"digitalocean_bandwidth_cost_usd",
+			"Amount in dollars of the current month's invoice items matching the bandwidth pattern",
+			nil, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *BillingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.MonthToDateUsage
+	ch <- c.AccountBalance
+	ch <- c.SpacesCost
+	ch <- c.BandwidthCost
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *BillingCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	succeeded := true
+	if err := c.collectBalance(ctx, ch); err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't get balance",
+			"err", err,
+		)
+		succeeded = false
+	}
+
+	items, err := listInvoicePreviewItems(ctx, c.client, c.perPage)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list invoice preview items",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	var spaces, bandwidth float64
+	for _, item := range items {
+		amount, err := strconv.ParseFloat(item.Amount, 64)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't parse amount of invoice item",
+				"product", item.Product,
+				"err", err,
+			)
+			succeeded = false
+			continue
+		}
+		// An item is matched by its product and description together, like "Spaces Subscription Outbound transfer".
+		text := item.Product + " " + item.Description
+		if c.spaces.MatchString(text) {
+			spaces += amount
+		}
+		if c.bandwidth.MatchString(text) {
+			bandwidth += amount
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.SpacesCost,
+		prometheus.GaugeValue,
+		spaces,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.BandwidthCost,
+		prometheus.GaugeValue,
+		bandwidth,
+	)
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+// collectBalance sends the month-to-date usage and the balance of the account.
+func (c *BillingCollector) collectBalance(ctx context.Context, ch chan<- prometheus.Metric) error {
+	req, err := c.client.NewRequest(ctx, http.MethodGet, "v2/customers/my/balance", nil)
+	if err != nil {
+		return err
+	}
+	var balance billingBalance
+	if _, err := c.client.Do(ctx, req, &balance); err != nil {
+		return err
+	}
+
+	usage, err := strconv.ParseFloat(balance.MonthToDateUsage, 64)
+	if err != nil {
+		return fmt.Errorf("invalid month to date usage: %v", err)
+	}
+	accountBalance, err := strconv.ParseFloat(balance.AccountBalance, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account balance: %v", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.MonthToDateUsage,
+		prometheus.GaugeValue,
+		usage,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.AccountBalance,
+		prometheus.GaugeValue,
+		accountBalance,
+	)
+	return nil
+}
+
+// listInvoicePreviewItems lists the items of the invoice preview of the current month.
+func listInvoicePreviewItems(ctx context.Context, client *godo.Client, perPage int) ([]invoiceItem, error) {
+	// Every page of the invoices has the preview, so the first one with a single invoice is enough.
+	preview := struct {
+		InvoicePreview struct {
+			InvoiceUUID string `json:"invoice_uuid"`
+		} `json:"invoice_preview"`
+		rawPage
+	}{}
+	if _, err := getPage(ctx, client, "v2/customers/my/invoices", &godo.ListOptions{PerPage: 1}, &preview); err != nil {
+		return nil, err
+	}
+	uuid := preview.InvoicePreview.InvoiceUUID
+	if uuid == "" {
+		return nil, fmt.Errorf("no invoice preview in response")
+	}
+
+	var items []invoiceItem
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			InvoiceItems []invoiceItem `json:"invoice_items"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/customers/my/invoices/"+uuid, opt, &root)
+		items = append(items, root.InvoiceItems...)
+		return resp, err
+	})
+	return items, err
+}
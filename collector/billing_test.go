@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"regexp"
+	"testing"
+)
+
+var billingFixtures = map[string]string{
+	"/v2/customers/my/balance": `{"month_to_date_balance":"23.44","account_balance":"12.23","month_to_date_usage":"11.21"}`,
+	"/v2/customers/my/invoices": `{"invoices":[{"invoice_uuid":"i1","amount":"20.00","invoice_period":"2026-09"}],
+		"invoice_preview":{"invoice_uuid":"preview","amount":"11.21","invoice_period":"2026-10"},"links":{}}`,
+	"/v2/customers/my/invoices/preview": `{"invoice_items":[
+		{"product":"Spaces Subscription","description":"Spaces (250 GB storage and 1 TB outbound transfer)","amount":"5.00"},
+		{"product":"Spaces Subscription","description":"Additional outbound transfer","amount":"1.50"},
+		{"product":"Droplets","description":"web-1 (s-1vcpu-1gb)","amount":"4.21"},
+		{"product":"Bandwidth","description":"Droplet outbound transfer overage","amount":"0.50"}
+	],"links":{}}`,
+}
+
+func testBillingConfig(t *testing.T, api *testAPI) Config {
+	c := testConfig(api.client(t))
+	c.Billing = true
+	c.BillingSpacesMatch = regexp.MustCompile("(?i)spaces")
+	c.BillingBandwidthMatch = regexp.MustCompile("(?i)bandwidth|transfer")
+	return c
+}
+
+func TestBillingCollector(t *testing.T) {
+	api := newTestAPI(t, billingFixtures)
+	mfs := gather(t, newTestCollector(t, "billing", testBillingConfig(t, api)))
+
+	assertMetric(t, mfs, 11.21, "digitalocean_billing_month_to_date_usage_usd")
+	assertMetric(t, mfs, 12.23, "digitalocean_billing_account_balance_usd")
+	assertMetric(t, mfs, 6.5, "digitalocean_spaces_cost_usd")
+	// The Spaces items with transfer in their description are bandwidth too.
+	assertMetric(t, mfs, 7, "digitalocean_bandwidth_cost_usd")
+	if n := api.requested("/v2/customers/my/invoices/i1"); n != 0 {
+		t.Errorf("the items of a past invoice were listed %d times, want 0", n)
+	}
+}
+
+func TestBillingCollectorMatch(t *testing.T) {
+	api := newTestAPI(t, billingFixtures)
+	c := testBillingConfig(t, api)
+	c.BillingBandwidthMatch = regexp.MustCompile("^Bandwidth ")
+	mfs := gather(t, newTestCollector(t, "billing", c))
+
+	assertMetric(t, mfs, 6.5, "digitalocean_spaces_cost_usd")
+	assertMetric(t, mfs, 0.5, "digitalocean_bandwidth_cost_usd")
+}
+
+func TestBillingCollectorDisabled(t *testing.T) {
+	api := newTestAPI(t, nil)
+	if _, ok := Collectors(testConfig(api.client(t)))["billing"]; ok {
+		t.Error("the billing collector was built without Billing")
+	}
+}
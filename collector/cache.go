@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/digitalocean/godo"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ScrapeCache shares the droplets, volumes, floating ips and database clusters of an account between
+// its collectors, so that they're listed once per scrape instead of by every
+// collector that needs them. The lists are forgotten before every gathering of
+// a Gatherer returned by Gatherer.
+type ScrapeCache struct {
+	client  *godo.Client
+	perPage int
+	ids     []int
+
+	mu    sync.Mutex
+	lists map[string]*cachedList
+}
+
+// cachedList is a list of resources, listed once by the first collector asking for it.
+type cachedList struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// NewScrapeCache returns a new ScrapeCache listing with the client.
+// With dropletIDs only these droplets are got one by one for the droplet collector
+// and the collectors cross-referencing droplets, instead of listing all droplets.
+func NewScrapeCache(client *godo.Client, perPage int, dropletIDs []int) *ScrapeCache {
+	return &ScrapeCache{
+		client:  client,
+		perPage: perPage,
+		ids:     dropletIDs,
+		lists:   map[string]*cachedList{},
+	}
+}
+
+// Gatherer returns a Gatherer that forgets the lists of the previous scrape before every gathering of g.
+func (c *ScrapeCache) Gatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return scrapeGatherer{cache: c, gatherer: g}
+}
+
+type scrapeGatherer struct {
+	cache    *ScrapeCache
+	gatherer prometheus.Gatherer
+}
+
+// Gather implements prometheus.Gatherer.
+func (g scrapeGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.cache.reset()
+	return g.gatherer.Gather()
+}
+
+// reset forgets all lists. Collectors still listing keep their list to themselves.
+func (c *ScrapeCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lists = map[string]*cachedList{}
+}
+
+// get returns the list cached under key, listing it first if it isn't cached yet.
+// Concurrent callers wait for the first one, whose ctx the list is listed with,
+// so the pages are counted for the collector that asked first.
+func (c *ScrapeCache) get(key string, list func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	l, ok := c.lists[key]
+	if !ok {
+		l = &cachedList{}
+		c.lists[key] = l
+	}
+	c.mu.Unlock()
+
+	l.once.Do(func() { l.value, l.err = list() })
+	return l.value, l.err
+}
+
+// allDroplets returns all droplets of the account.
+func (c *ScrapeCache) allDroplets(ctx context.Context) ([]godo.Droplet, error) {
+	droplets, err := c.get("droplets", func() (interface{}, error) {
+		return listDroplets(ctx, c.client, c.perPage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return droplets.([]godo.Droplet), nil
+}
+
+// volumes returns all volumes of the account.
+func (c *ScrapeCache) volumes(ctx context.Context) ([]godo.Volume, error) {
+	volumes, err := c.get("volumes", func() (interface{}, error) {
+		return listVolumes(ctx, c.client, c.perPage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return volumes.([]godo.Volume), nil
+}
+
+// floatingIPs returns all floating ips of the account.
+func (c *ScrapeCache) floatingIPs(ctx context.Context) ([]godo.FloatingIP, error) {
+	floatingIPs, err := c.get("floating_ips", func() (interface{}, error) {
+		return listFloatingIPs(ctx, c.client, c.perPage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return floatingIPs.([]godo.FloatingIP), nil
+}
+
+// databases returns all database clusters of the account.
+func (c *ScrapeCache) databases(ctx context.Context) ([]databaseCluster, error) {
+	databases, err := c.get("databases", func() (interface{}, error) {
+		return listDatabases(ctx, c.client, c.perPage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return databases.([]databaseCluster), nil
+}
+
+// dropletScope are the droplets the exporter collects.
+type dropletScope struct {
+	droplets []godo.Droplet
+	// notFound are the configured droplet IDs that weren't found.
+	notFound []int
+	// ids are the configured droplet IDs, nil if all droplets are collected.
+	ids map[int]bool
+}
+
+// known reports whether the droplet with the id is in the scope,
+// so that it would be one of its droplets if it existed.
+func (s *dropletScope) known(id int) bool {
+	return s.ids == nil || s.ids[id]
+}
+
+// droplets returns the droplets the exporter collects: with droplet IDs only these,
+// got one by one, otherwise all droplets of the account.
+func (c *ScrapeCache) droplets(ctx context.Context) (*dropletScope, error) {
+	if len(c.ids) == 0 {
+		droplets, err := c.allDroplets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &dropletScope{droplets: droplets}, nil
+	}
+
+	scope, err := c.get("droplet_ids", func() (interface{}, error) {
+		return c.getDroplets(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scope.(*dropletScope), nil
+}
+
+// getDroplets gets the configured droplets by their IDs.
+func (c *ScrapeCache) getDroplets(ctx context.Context) (*dropletScope, error) {
+	scope := &dropletScope{
+		droplets: make([]godo.Droplet, 0, len(c.ids)),
+		ids:      make(map[int]bool, len(c.ids)),
+	}
+	for _, id := range c.ids {
+		scope.ids[id] = true
+
+		droplet, resp, err := c.client.Droplets.Get(ctx, id)
+		if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+			scope.notFound = append(scope.notFound, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scope.droplets = append(scope.droplets, *droplet)
+	}
+	return scope, nil
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var cacheFixtures = map[string]string{
+	"/v2/account": `{"account":{"droplet_limit":10,"floating_ip_limit":5,"volume_limit":10,"status":"active"}}`,
+	"/v2/droplets": `{"droplets":[
+		{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{}},
+		{"id":2,"name":"db","region":{"slug":"fra1"},"status":"active","size":{}}
+	],"links":{}}`,
+	"/v2/droplets/1": `{"droplet":{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{}}}`,
+	"/v2/volumes": `{"volumes":[
+		{"id":"v1","name":"data","region":{"slug":"nyc1"},"size_gigabytes":10,"droplet_ids":[1]}
+	],"links":{}}`,
+	"/v2/floating_ips": `{"floating_ips":[{"ip":"203.0.113.1","region":{"slug":"nyc1"},"droplet":{"id":1,"name":"web"}}],"links":{}}`,
+	"/v2/snapshots": `{"snapshots":[
+		{"id":"s1","name":"web-snap","regions":["nyc1"],"resource_id":"1","resource_type":"droplet"},
+		{"id":"s2","name":"db-snap","regions":["fra1"],"resource_id":"2","resource_type":"droplet"},
+		{"id":"s3","name":"gone-snap","regions":["fra1"],"resource_id":"3","resource_type":"droplet"},
+		{"id":"s4","name":"data-snap","regions":["nyc1"],"resource_id":"v1","resource_type":"volume"},
+		{"id":"s5","name":"old-snap","regions":["nyc1"],"resource_id":"v9","resource_type":"volume"}
+	],"links":{}}`,
+	"/v2/databases":      `{"databases":[{"id":"d1","name":"main","engine":"pg","region":"nyc1","status":"online"}]}`,
+	"/v2/actions":        `{"actions":[],"links":{}}`,
+	"/v2/load_balancers": `{"load_balancers":[{"id":"lb1","name":"front","ip":"203.0.113.9","region":{"slug":"nyc1"},"droplet_ids":[1]}],"links":{}}`,
+}
+
+// registry registers the named collectors built from c with a registry
+// gathered through the cache, like main does.
+func registry(t *testing.T, c Config, names ...string) prometheus.Gatherer {
+	r := prometheus.NewPedanticRegistry()
+	for _, name := range names {
+		if err := r.Register(newTestCollector(t, name, c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c.Cache.Gatherer(r)
+}
+
+func TestScrapeCacheListsOncePerScrape(t *testing.T) {
+	api := newTestAPI(t, cacheFixtures)
+	c := testConfig(api.client(t))
+	c.AccountLimitUsage = true
+	c.DatabaseMetrics = true
+	g := registry(t, c, "account", "database", "database_metrics", "droplet", "floating_ip", "loadbalancer", "snapshot", "volume")
+
+	for scrape := 1; scrape <= 2; scrape++ {
+		if _, err := g.Gather(); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{"/v2/databases", "/v2/droplets", "/v2/floating_ips", "/v2/volumes"} {
+			if n := api.requested(path); n != scrape {
+				t.Errorf("scrape %d: %s was requested %d times, want %d", scrape, path, n, scrape)
+			}
+		}
+	}
+}
+
+func TestSnapshotCollectorOrphaned(t *testing.T) {
+	api := newTestAPI(t, cacheFixtures)
+	mfs := gather(t, newTestCollector(t, "snapshot", testConfig(api.client(t))))
+
+	labels := func(id, name, region, resourceType string) []string {
+		return []string{"id=" + id, "name=" + name, "region=" + region, "type=" + resourceType}
+	}
+	assertMetric(t, mfs, 0, "digitalocean_snapshot_orphaned", labels("s1", "web-snap", "nyc1", "droplet")...)
+	assertMetric(t, mfs, 0, "digitalocean_snapshot_orphaned", labels("s2", "db-snap", "fra1", "droplet")...)
+	assertMetric(t, mfs, 1, "digitalocean_snapshot_orphaned", labels("s3", "gone-snap", "fra1", "droplet")...)
+	assertMetric(t, mfs, 0, "digitalocean_snapshot_orphaned", labels("s4", "data-snap", "nyc1", "volume")...)
+	assertMetric(t, mfs, 1, "digitalocean_snapshot_orphaned", labels("s5", "old-snap", "nyc1", "volume")...)
+}
+
+func TestSnapshotCollectorOrphanedDropletIDs(t *testing.T) {
+	api := newTestAPI(t, cacheFixtures)
+	c := testConfig(api.client(t))
+	c.Cache = NewScrapeCache(c.Client, c.PerPage, []int{1, 3})
+	mfs := gather(t, newTestCollector(t, "snapshot", c))
+
+	// Only the snapshots of the configured droplets are known, all droplets aren't listed.
+	assertMetric(t, mfs, 0, "digitalocean_snapshot_orphaned", "id=s1", "name=web-snap", "region=nyc1", "type=droplet")
+	assertNoMetric(t, mfs, "digitalocean_snapshot_orphaned", "id=s2", "name=db-snap", "region=fra1", "type=droplet")
+	assertMetric(t, mfs, 1, "digitalocean_snapshot_orphaned", "id=s3", "name=gone-snap", "region=fra1", "type=droplet")
+	if n := api.requested("/v2/droplets"); n != 0 {
+		t.Errorf("droplets were listed %d times, want 0", n)
+	}
+}
+
+func TestSnapshotCollectorOrphanedDisabled(t *testing.T) {
+	api := newTestAPI(t, cacheFixtures)
+	c := testConfig(api.client(t))
+	c.Disabled = []string{"volume"}
+	mfs := gather(t, newTestCollector(t, "snapshot", c))
+
+	assertNoMetric(t, mfs, "digitalocean_snapshot_orphaned", "id=s1", "name=web-snap", "region=nyc1", "type=droplet")
+	if n := api.requested("/v2/droplets") + api.requested("/v2/volumes"); n != 0 {
+		t.Errorf("droplets and volumes were listed %d times, want 0", n)
+	}
+}
@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("certificate", func(c Config) prometheus.Collector {
+		return NewCertificateCollector(c)
+	})
+}
+
+// certificateWithState is a certificate with its type and state,
+// which the vendored godo doesn't decode.
+type certificateWithState struct {
+	godo.Certificate
+	// Type is either custom or lets_encrypt.
+	Type string `json:"type"`
+	// State is one of pending, verified or error.
+	State string `json:"state"`
+}
+
+// CertificateCollector collects metrics about the certificates of the account.
+type CertificateCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	*lastSuccess
+	pages *pageCounter
+
+	PendingRenewal *prometheus.Desc
+	ByType         *prometheus.Desc
+}
+
+// NewCertificateCollector returns a new CertificateCollector built from the Config.
+func NewCertificateCollector(c Config) *CertificateCollector {
+	return &CertificateCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		lastSuccess: newLastSuccess("certificate"),
+		pages:       newPageCounter("certificate"),
+
+		PendingRenewal: prometheus.NewDesc(
+			"digitalocean_certificate_pending_renewal",
+			"If 1 the Let's Encrypt certificate is pending or its issuance failed, 0 if it's verified",
+			[]string{"id", "name"}, nil,
+		),
+		ByType: prometheus.NewDesc(
+			"digitalocean_certificates_by_type",
+			"Number of certificates by their type, custom or lets_encrypt",
+			[]string{"type"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *CertificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.PendingRenewal
+	ch <- c.ByType
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *CertificateCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	certificates, err := listCertificates(ctx, c.client, c.perPage)
+	c.lastSuccess.collect(ch, err == nil)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list certificates",
+			"err", err,
+		)
+		return
+	}
+
+	byType := map[string]int{}
+	for _, certificate := range certificates {
+		byType[certificate.Type]++
+
+		// Custom certificates are uploaded, they're never renewed by DigitalOcean.
+		if certificate.Type != "lets_encrypt" {
+			continue
+		}
+		var pending float64
+		if certificate.State != "verified" {
+			pending = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.PendingRenewal,
+			prometheus.GaugeValue,
+			pending,
+			certificate.ID, certificate.Name,
+		)
+	}
+
+	for certificateType, count := range byType {
+		ch <- prometheus.MustNewConstMetric(
+			c.ByType,
+			prometheus.GaugeValue,
+			float64(count),
+			certificateType,
+		)
+	}
+}
+
+func listCertificates(ctx context.Context, client *godo.Client, perPage int) ([]certificateWithState, error) {
+	var certificates []certificateWithState
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Certificates []certificateWithState `json:"certificates"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/certificates", opt, &root)
+		certificates = append(certificates, root.Certificates...)
+		return resp, err
+	})
+	return certificates, err
+}
@@ -0,0 +1,24 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestCertificateCollector(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/certificates": `{"certificates":[
+			{"id":"c1","name":"web","not_after":"2099-01-01T00:00:00Z","type":"lets_encrypt","state":"verified"},
+			{"id":"c2","name":"api","not_after":"2020-01-01T00:00:00Z","type":"lets_encrypt","state":"error"},
+			{"id":"c3","name":"new","type":"lets_encrypt","state":"pending"},
+			{"id":"c4","name":"uploaded","not_after":"2099-01-01T00:00:00Z","type":"custom","state":"verified"}
+		],"links":{}}`,
+	})
+	mfs := gather(t, newTestCollector(t, "certificate", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 0, "digitalocean_certificate_pending_renewal", "id=c1", "name=web")
+	assertMetric(t, mfs, 1, "digitalocean_certificate_pending_renewal", "id=c2", "name=api")
+	assertMetric(t, mfs, 1, "digitalocean_certificate_pending_renewal", "id=c3", "name=new")
+	assertNoMetric(t, mfs, "digitalocean_certificate_pending_renewal", "id=c4", "name=uploaded")
+	assertMetric(t, mfs, 3, "digitalocean_certificates_by_type", "type=lets_encrypt")
+	assertMetric(t, mfs, 1, "digitalocean_certificates_by_type", "type=custom")
+}
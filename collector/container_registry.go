@@ -0,0 +1,211 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("registry", func(c Config) prometheus.Collector {
+		return NewContainerRegistryCollector(c)
+	})
+}
+
+// containerRegistry is the container registry of the account, which the vendored godo doesn't know about.
+type containerRegistry struct {
+	Name              string `json:"name"`
+	Region            string `json:"region"`
+	StorageUsageBytes int64  `json:"storage_usage_bytes"`
+}
+
+// garbageCollection is a garbage collection of a container registry.
+type garbageCollection struct {
+	UUID string `json:"uuid"`
+	// Status is one of requested, waiting for write JWTs to expire, scanning manifests,
+	// deleting unreferenced blobs, cancelling, failed, succeeded or cancelled.
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	BlobsDeleted int64     `json:"blobs_deleted"`
+	FreedBytes   int64     `json:"freed_bytes"`
+}
+
+// ContainerRegistryCollector collects metrics about the container registry of the account.
+type ContainerRegistryCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	regions []string
+	*lastSuccess
+	pages *pageCounter
+
+	StorageUsage *prometheus.Desc
+	GCStatus     *prometheus.Desc
+	GCBlobs      *prometheus.Desc
+	GCUpdated    *prometheus.Desc
+}
+
+// NewContainerRegistryCollector returns a new ContainerRegistryCollector built from the Config.
+func NewContainerRegistryCollector(c Config) *ContainerRegistryCollector {
+	labels := []string{"name", "region"}
+
+	return &ContainerRegistryCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		lastSuccess: newLastSuccess("registry"),
+		pages:       newPageCounter("registry"),
+
+		StorageUsage: prometheus.NewDesc(
+			"digitalocean_registry_storage_usage_bytes",
+			"Storage used by the container registry in bytes",
+			labels, nil,
+		),
+		GCStatus: prometheus.NewDesc(
+			"digitalocean_registry_gc_status",
+			"A metric with a constant '1' value labeled by the state of the container registry's latest garbage collection, none if it never ran one",
+			append(labels, "state"), nil,
+		),
+		GCBlobs: prometheus.NewDesc(
+			"digitalocean_registry_gc_blobs_deleted",
+			"Number of blobs deleted by the container registry's latest garbage collection",
+			labels, nil,
+		),
+		GCUpdated: prometheus.NewDesc(
+			"digitalocean_registry_gc_updated_timestamp_seconds",
+			"Unix timestamp of the last update of the container registry's latest garbage collection",
+			labels, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *ContainerRegistryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.StorageUsage
+	ch <- c.GCStatus
+	ch <- c.GCBlobs
+	ch <- c.GCUpdated
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *ContainerRegistryCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	registry, err := getContainerRegistry(ctx, c.client)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't get container registry",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+	// Accounts without a registry have nothing to collect.
+	if registry == nil || !inRegionSlugs(c.regions, registry.Region) {
+		c.lastSuccess.collect(ch, true)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.StorageUsage,
+		prometheus.GaugeValue,
+		float64(registry.StorageUsageBytes),
+		registry.Name, registry.Region,
+	)
+
+	gc, err := latestGarbageCollection(ctx, c.client, c.perPage, registry.Name)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list garbage collections of container registry",
+			"registry", registry.Name,
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+	if gc == nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.GCStatus,
+			prometheus.GaugeValue,
+			1.0,
+			registry.Name, registry.Region, "none",
+		)
+		c.lastSuccess.collect(ch, true)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.GCStatus,
+		prometheus.GaugeValue,
+		1.0,
+		registry.Name, registry.Region, gc.Status,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.GCBlobs,
+		prometheus.GaugeValue,
+		float64(gc.BlobsDeleted),
+		registry.Name, registry.Region,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.GCUpdated,
+		prometheus.GaugeValue,
+		float64(gc.UpdatedAt.Unix()),
+		registry.Name, registry.Region,
+	)
+
+	c.lastSuccess.collect(ch, true)
+}
+
+// getContainerRegistry gets the container registry of the account, nil if it doesn't have one.
+func getContainerRegistry(ctx context.Context, client *godo.Client) (*containerRegistry, error) {
+	req, err := client.NewRequest(ctx, http.MethodGet, "v2/registry", nil)
+	if err != nil {
+		return nil, err
+	}
+	root := struct {
+		Registry *containerRegistry `json:"registry"`
+	}{}
+	resp, err := client.Do(ctx, req, &root)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return root.Registry, nil
+}
+
+// latestGarbageCollection returns the most recently created garbage collection of the
+// container registry with the name, nil if it never ran one.
+func latestGarbageCollection(ctx context.Context, client *godo.Client, perPage int, name string) (*garbageCollection, error) {
+	var latest *garbageCollection
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			GarbageCollections []garbageCollection `json:"garbage_collections"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/registry/"+name+"/garbage-collections", opt, &root)
+		for i, gc := range root.GarbageCollections {
+			if latest == nil || gc.CreatedAt.After(latest.CreatedAt) {
+				latest = &root.GarbageCollections[i]
+			}
+		}
+		return resp, err
+	})
+	return latest, err
+}
@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"testing"
+)
+
+const testContainerRegistry = `{"registry":{"name":"example","region":"fra1","storage_usage_bytes":1073741824}}`
+
+func TestContainerRegistryCollector(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/registry": testContainerRegistry,
+		"/v2/registry/example/garbage-collections": `{"garbage_collections":[
+			{"uuid":"gc2","registry_name":"example","status":"scanning manifests","created_at":"2026-10-13T10:00:00Z","updated_at":"2026-10-13T10:05:00Z","blobs_deleted":0},
+			{"uuid":"gc1","registry_name":"example","status":"succeeded","created_at":"2026-10-01T10:00:00Z","updated_at":"2026-10-01T10:30:00Z","blobs_deleted":42}
+		],"links":{}}`,
+	})
+	mfs := gather(t, newTestCollector(t, "registry", testConfig(api.client(t))))
+
+	registry := []string{"name=example", "region=fra1"}
+	assertMetric(t, mfs, 1073741824, "digitalocean_registry_storage_usage_bytes", registry...)
+	// Only the latest garbage collection counts, though it's still running.
+	assertMetric(t, mfs, 1, "digitalocean_registry_gc_status", append(registry, "state=scanning manifests")...)
+	assertNoMetric(t, mfs, "digitalocean_registry_gc_status", append(registry, "state=succeeded")...)
+	assertMetric(t, mfs, 0, "digitalocean_registry_gc_blobs_deleted", registry...)
+	assertMetric(t, mfs, 1791885900, "digitalocean_registry_gc_updated_timestamp_seconds", registry...)
+}
+
+func TestContainerRegistryCollectorNeverCollected(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/registry": testContainerRegistry,
+		"/v2/registry/example/garbage-collections": `{"garbage_collections":[],"links":{}}`,
+	})
+	mfs := gather(t, newTestCollector(t, "registry", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 1, "digitalocean_registry_gc_status", "name=example", "region=fra1", "state=none")
+	assertNoMetric(t, mfs, "digitalocean_registry_gc_blobs_deleted", "name=example", "region=fra1")
+}
+
+func TestContainerRegistryCollectorNoRegistry(t *testing.T) {
+	api := newTestAPI(t, nil)
+	mfs := gather(t, newTestCollector(t, "registry", testConfig(api.client(t))))
+
+	assertNoMetric(t, mfs, "digitalocean_registry_storage_usage_bytes", "name=example", "region=fra1")
+	// An account without a registry isn't an error.
+	if _, ok := metricValue(mfs, "digitalocean_collector_last_success_timestamp_seconds", "collector=registry"); !ok {
+		t.Error("collecting an account without a registry failed")
+	}
+}
@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newCreated24hDesc returns the descriptor of the number of resources of
+// resourceType created within the last 24 hours. All collectors with a
+// creation time share it, to have a single metric for the whole account.
+func newCreated24hDesc(resourceType string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		"digitalocean_resources_created_24h",
+		"Number of resources created within the last 24 hours, by resource type",
+		nil, prometheus.Labels{"resource_type": resourceType},
+	)
+}
+
+// createdWithin24h reports whether the creation time is within the last 24 hours.
+func createdWithin24h(created time.Time) bool {
+	return time.Since(created) <= 24*time.Hour
+}
+
+// createdWithin24hRFC3339 is createdWithin24h for creation times as returned by the API.
+// Creation times that can't be parsed aren't within the last 24 hours.
+func createdWithin24hRFC3339(created string) bool {
+	t, err := time.Parse(time.RFC3339, created)
+	return err == nil && createdWithin24h(t)
+}
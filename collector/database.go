@@ -0,0 +1,393 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("database", func(c Config) prometheus.Collector {
+		return NewDatabaseCollector(c)
+	})
+}
+
+// databaseCluster is a managed database cluster, which the vendored godo doesn't know about.
+type databaseCluster struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Engine   string `json:"engine"`
+	Version  string `json:"version"`
+	Size     string `json:"size"`
+	Region   string `json:"region"`
+	NumNodes int    `json:"num_nodes"`
+	// Status is one of creating, online, resizing, migrating or forking.
+	Status            string                     `json:"status"`
+	MaintenanceWindow *databaseMaintenanceWindow `json:"maintenance_window"`
+	// MetricsEndpoints serve the cluster's metrics in the Prometheus format, one per node.
+	MetricsEndpoints []struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	} `json:"metrics_endpoints"`
+}
+
+// databaseMaintenanceWindow is the weekly window updates of a database cluster are applied in.
+type databaseMaintenanceWindow struct {
+	// Day is the lower case day of the week, like tuesday.
+	Day string `json:"day"`
+	// Hour is the UTC time of day the window starts at, like 03:00:00.
+	Hour string `json:"hour"`
+	// Pending is whether there are updates to apply in the next window.
+	Pending bool `json:"pending"`
+}
+
+// databaseReplica is a read-only replica of a managed database cluster.
+type databaseReplica struct {
+	Name string `json:"name"`
+	// Region may differ from the region of the replica's cluster.
+	Region string `json:"region"`
+	// Status is one of creating, online, resizing or migrating like the cluster's.
+	Status string `json:"status"`
+}
+
+// DatabaseCollector collects metrics about the managed database clusters of the account.
+type DatabaseCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	regions []string
+	cache   *ScrapeCache
+	details bool
+	*lastSuccess
+	pages *pageCounter
+
+	Info               *prometheus.Desc
+	Up                 *prometheus.Desc
+	Nodes              *prometheus.Desc
+	Replicas           *prometheus.Desc
+	ReplicaUp          *prometheus.Desc
+	ConnectionPools    *prometheus.Desc
+	Users              *prometheus.Desc
+	DBs                *prometheus.Desc
+	MaintenancePending *prometheus.Desc
+}
+
+// NewDatabaseCollector returns a new DatabaseCollector built from the Config.
+// With DatabaseIncludeDetails every cluster's read-only replicas, connection pools,
+// users and databases are listed, which is up to four more API calls per cluster.
+func NewDatabaseCollector(c Config) *DatabaseCollector {
+	labels := []string{"id", "name", "region"}
+
+	return &DatabaseCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		cache:       c.Cache,
+		details:     c.DatabaseIncludeDetails,
+		lastSuccess: newLastSuccess("database"),
+		pages:       newPageCounter("database"),
+
+		Info: prometheus.NewDesc(
+			"digitalocean_database_info",
+			"A metric with a constant '1' value labeled by the database cluster's engine, version and size",
+			append(labels, "engine", "version", "size"), nil,
+		),
+		Up: prometheus.NewDesc(
+			"digitalocean_database_up",
+			"If 1 the database cluster is online, 0 otherwise, like while it's creating, resizing or migrating",
+			labels, nil,
+		),
+		Nodes: prometheus.NewDesc(
+			"digitalocean_database_nodes",
+			"Number of nodes of the database cluster, not counting its read-only replicas",
+			labels, nil,
+		),
+		Replicas: prometheus.NewDesc(
+			"digitalocean_database_replica_count",
+			"Number of read-only replicas of the database cluster",
+			labels, nil,
+		),
+		ReplicaUp: prometheus.NewDesc(
+			"digitalocean_database_replica_up",
+			"If 1 the read-only replica of the database cluster is online, 0 otherwise",
+			[]string{"database_id", "database_name", "name", "region"}, nil,
+		),
+		ConnectionPools: prometheus.NewDesc(
+			"digitalocean_database_connection_pools",
+			"Number of connection pools of the PostgreSQL database cluster",
+			labels, nil,
+		),
+		Users: prometheus.NewDesc(
+			"digitalocean_database_user_count",
+			"Number of users of the database cluster",
+			labels, nil,
+		),
+		DBs: prometheus.NewDesc(
+			"digitalocean_database_db_count",
+			"Number of databases within the database cluster",
+			labels, nil,
+		),
+		MaintenancePending: prometheus.NewDesc(
+			"digitalocean_database_maintenance_pending",
+			"If 1 there are updates pending for the database cluster's next maintenance window, 0 otherwise, labeled by the window's day and UTC hour",
+			append(labels, "day", "hour"), nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *DatabaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Info
+	ch <- c.Up
+	ch <- c.Nodes
+	ch <- c.Replicas
+	ch <- c.ReplicaUp
+	ch <- c.ConnectionPools
+	ch <- c.Users
+	ch <- c.DBs
+	ch <- c.MaintenancePending
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *DatabaseCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	databases, err := c.cache.databases(ctx)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list databases",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	succeeded := true
+	for _, db := range databases {
+		if !inRegionSlugs(c.regions, db.Region) {
+			continue
+		}
+		labels := []string{db.ID, db.Name, db.Region}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Info,
+			prometheus.GaugeValue,
+			1.0,
+			append(labels, db.Engine, db.Version, db.Size)...,
+		)
+
+		var up float64
+		if db.Status == "online" {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Up,
+			prometheus.GaugeValue,
+			up,
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.Nodes,
+			prometheus.GaugeValue,
+			float64(db.NumNodes),
+			labels...,
+		)
+
+		// Clusters that are still creating don't have a maintenance window yet.
+		if w := db.MaintenanceWindow; w != nil {
+			var pending float64
+			if w.Pending {
+				pending = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.MaintenancePending,
+				prometheus.GaugeValue,
+				pending,
+				append(labels, w.Day, w.Hour)...,
+			)
+		}
+
+		if c.details && !c.collectDetails(ctx, ch, db) {
+			succeeded = false
+		}
+	}
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+// collectDetails sends the metrics of the cluster's replicas, connection pools,
+// users and databases, and reports whether all of them could be listed.
+// They share the collector's timeout with the clusters.
+func (c *DatabaseCollector) collectDetails(ctx context.Context, ch chan<- prometheus.Metric, db databaseCluster) bool {
+	labels := []string{db.ID, db.Name, db.Region}
+	succeeded := true
+
+	replicas, err := listDatabaseReplicas(ctx, c.client, c.perPage, db.ID)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list replicas of database",
+			"database", db.Name,
+			"err", err,
+		)
+		succeeded = false
+	} else {
+		c.collectReplicas(ch, db, replicas)
+	}
+
+	// Only PostgreSQL clusters have connection pools.
+	if db.Engine == "pg" {
+		pools, err := countDatabasePools(ctx, c.client, c.perPage, db.ID)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list connection pools of database",
+				"database", db.Name,
+				"err", err,
+			)
+			succeeded = false
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.ConnectionPools, prometheus.GaugeValue, float64(pools), labels...)
+		}
+	}
+
+	// Redis clusters have neither users nor databases to manage, Kafka clusters have topics instead of databases.
+	if db.Engine == "redis" {
+		return succeeded
+	}
+	users, err := countDatabaseUsers(ctx, c.client, c.perPage, db.ID)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list users of database",
+			"database", db.Name,
+			"err", err,
+		)
+		succeeded = false
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.Users, prometheus.GaugeValue, float64(users), labels...)
+	}
+
+	if db.Engine == "kafka" {
+		return succeeded
+	}
+	dbs, err := countDatabaseDBs(ctx, c.client, c.perPage, db.ID)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list databases of database cluster",
+			"database", db.Name,
+			"err", err,
+		)
+		succeeded = false
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.DBs, prometheus.GaugeValue, float64(dbs), labels...)
+	}
+	return succeeded
+}
+
+// collectReplicas sends the number of the cluster's read-only replicas, 0 if it has none, and whether each of them is up.
+func (c *DatabaseCollector) collectReplicas(ch chan<- prometheus.Metric, db databaseCluster, replicas []databaseReplica) {
+	ch <- prometheus.MustNewConstMetric(
+		c.Replicas,
+		prometheus.GaugeValue,
+		float64(len(replicas)),
+		db.ID, db.Name, db.Region,
+	)
+
+	for _, replica := range replicas {
+		var up float64
+		if replica.Status == "online" {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.ReplicaUp,
+			prometheus.GaugeValue,
+			up,
+			db.ID, db.Name, replica.Name, replica.Region,
+		)
+	}
+}
+
+func listDatabases(ctx context.Context, client *godo.Client, perPage int) ([]databaseCluster, error) {
+	var databases []databaseCluster
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Databases []databaseCluster `json:"databases"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/databases", opt, &root)
+		databases = append(databases, root.Databases...)
+		return resp, err
+	})
+	return databases, err
+}
+
+// listDatabaseReplicas returns the read-only replicas of the database cluster.
+func listDatabaseReplicas(ctx context.Context, client *godo.Client, perPage int, id string) ([]databaseReplica, error) {
+	var replicas []databaseReplica
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Replicas []databaseReplica `json:"replicas"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/databases/"+id+"/replicas", opt, &root)
+		replicas = append(replicas, root.Replicas...)
+		return resp, err
+	})
+	return replicas, err
+}
+
+// countDatabasePools returns the number of connection pools of the PostgreSQL database cluster.
+func countDatabasePools(ctx context.Context, client *godo.Client, perPage int, id string) (int, error) {
+	var n int
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Pools []struct{} `json:"pools"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/databases/"+id+"/pools", opt, &root)
+		n += len(root.Pools)
+		return resp, err
+	})
+	return n, err
+}
+
+// countDatabaseUsers returns the number of users of the database cluster.
+func countDatabaseUsers(ctx context.Context, client *godo.Client, perPage int, id string) (int, error) {
+	var n int
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Users []struct{} `json:"users"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/databases/"+id+"/users", opt, &root)
+		n += len(root.Users)
+		return resp, err
+	})
+	return n, err
+}
+
+// countDatabaseDBs returns the number of databases within the database cluster.
+func countDatabaseDBs(ctx context.Context, client *godo.Client, perPage int, id string) (int, error) {
+	var n int
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			DBs []struct{} `json:"dbs"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/databases/"+id+"/dbs", opt, &root)
+		n += len(root.DBs)
+		return resp, err
+	})
+	return n, err
+}
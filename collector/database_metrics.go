@@ -0,0 +1,285 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func init() {
+	RegisterCollector("database_metrics", func(c Config) prometheus.Collector {
+		if !c.DatabaseMetrics {
+			return nil
+		}
+		return NewDatabaseMetricsCollector(c)
+	})
+}
+
+// databaseMetricsPrefix is prepended to the names of the metrics scraped from the clusters.
+const databaseMetricsPrefix = "digitalocean_database_"
+
+// DatabaseMetricsCollector scrapes the metrics endpoints of the managed database clusters,
+// which serve their CPU, memory, disk and connection metrics in the Prometheus format,
+// and exposes them prefixed with digitalocean_database_.
+// Their names depend on the clusters' engines, so they aren't described
+// and a pedantic registry rejects them.
+type DatabaseMetricsCollector struct {
+	logger   log.Logger
+	client   *godo.Client
+	timeout  time.Duration
+	regions  []string
+	cache    *ScrapeCache
+	username string
+	password string
+	*lastSuccess
+	pages *pageCounter
+
+	// clients trust the CA certificate of the cluster with their ID, which signs the endpoints'
+	// certificates. It doesn't change, so it's only got once.
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+// NewDatabaseMetricsCollector returns a new DatabaseMetricsCollector built from the Config.
+// Without a DatabaseMetricsUsername the credentials of the endpoints are got from the API.
+func NewDatabaseMetricsCollector(c Config) *DatabaseMetricsCollector {
+	return &DatabaseMetricsCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		regions:     c.Regions,
+		cache:       c.Cache,
+		username:    c.DatabaseMetricsUsername,
+		password:    c.DatabaseMetricsPassword,
+		lastSuccess: newLastSuccess("database_metrics"),
+		pages:       newPageCounter("database_metrics"),
+		clients:     map[string]*http.Client{},
+	}
+}
+
+// Describe sends the descriptors of the collector's own metrics.
+// The metrics of the clusters aren't known before they're scraped.
+func (c *DatabaseMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *DatabaseMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	databases, err := c.cache.databases(ctx)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list databases",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	username, password, err := c.credentials(ctx)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't get database metrics credentials",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	succeeded := true
+	for _, db := range databases {
+		if !inRegionSlugs(c.regions, db.Region) || len(db.MetricsEndpoints) == 0 {
+			continue
+		}
+
+		client, err := c.httpClient(ctx, db.ID)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't get CA certificate of database",
+				"database", db.Name,
+				"err", err,
+			)
+			succeeded = false
+			continue
+		}
+
+		// The endpoints share the collector's timeout with the clusters.
+		for _, endpoint := range db.MetricsEndpoints {
+			host := net.JoinHostPort(endpoint.Host, strconv.Itoa(endpoint.Port))
+			families, err := scrapeDatabaseMetrics(ctx, client, host, username, password)
+			if err != nil {
+				level.Warn(c.logger).Log(
+					"msg", "can't scrape metrics of database",
+					"database", db.Name,
+					"endpoint", host,
+					"err", err,
+				)
+				succeeded = false
+				continue
+			}
+			c.collectFamilies(ch, db, endpoint.Host, families)
+		}
+	}
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+// collectFamilies sends the scraped metrics labeled by the cluster and the endpoint they were scraped from.
+// Metrics that already have one of these labels are left out.
+func (c *DatabaseMetricsCollector) collectFamilies(ch chan<- prometheus.Metric, db databaseCluster, endpoint string, families map[string]*dto.MetricFamily) {
+	for name, family := range families {
+		for _, m := range family.GetMetric() {
+			labelNames := []string{"database_id", "database_name", "endpoint"}
+			labelValues := []string{db.ID, db.Name, endpoint}
+			for _, lp := range m.GetLabel() {
+				labelNames = append(labelNames, lp.GetName())
+				labelValues = append(labelValues, lp.GetValue())
+			}
+			desc := prometheus.NewDesc(databaseMetricsPrefix+name, family.GetHelp(), labelNames, nil)
+
+			metric, err := databaseMetric(desc, family.GetType(), m, labelValues)
+			if err != nil {
+				level.Debug(c.logger).Log(
+					"msg", "can't expose metric of database",
+					"database", db.Name,
+					"metric", name,
+					"err", err,
+				)
+				continue
+			}
+			ch <- metric
+		}
+	}
+}
+
+// databaseMetric returns the scraped metric m of the type t as a metric of desc.
+func databaseMetric(desc *prometheus.Desc, t dto.MetricType, m *dto.Metric, labelValues []string) (prometheus.Metric, error) {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue(), labelValues...)
+	case dto.MetricType_GAUGE:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), labelValues...)
+	case dto.MetricType_UNTYPED:
+		return prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.GetUntyped().GetValue(), labelValues...)
+	case dto.MetricType_SUMMARY:
+		quantiles := map[float64]float64{}
+		for _, q := range m.GetSummary().GetQuantile() {
+			quantiles[q.GetQuantile()] = q.GetValue()
+		}
+		return prometheus.NewConstSummary(desc, m.GetSummary().GetSampleCount(), m.GetSummary().GetSampleSum(), quantiles, labelValues...)
+	case dto.MetricType_HISTOGRAM:
+		buckets := map[float64]uint64{}
+		for _, b := range m.GetHistogram().GetBucket() {
+			// The +Inf bucket is the sample count.
+			if math.IsInf(b.GetUpperBound(), 1) {
+				continue
+			}
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		return prometheus.NewConstHistogram(desc, m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum(), buckets, labelValues...)
+	}
+	return nil, fmt.Errorf("unknown metric type %s", t)
+}
+
+// credentials returns the configured credentials of the metrics endpoints, or else the account's from the API.
+func (c *DatabaseMetricsCollector) credentials(ctx context.Context) (string, string, error) {
+	if c.username != "" {
+		return c.username, c.password, nil
+	}
+
+	req, err := c.client.NewRequest(ctx, http.MethodGet, "v2/databases/metrics/credentials", nil)
+	if err != nil {
+		return "", "", err
+	}
+	var root struct {
+		Credentials struct {
+			Username string `json:"basic_auth_username"`
+			Password string `json:"basic_auth_password"`
+		} `json:"credentials"`
+	}
+	if _, err := c.client.Do(ctx, req, &root); err != nil {
+		return "", "", err
+	}
+	return root.Credentials.Username, root.Credentials.Password, nil
+}
+
+// httpClient returns the client for the metrics endpoints of the database cluster with the id,
+// trusting the cluster's CA certificate.
+func (c *DatabaseMetricsCollector) httpClient(ctx context.Context, id string) (*http.Client, error) {
+	c.mu.Lock()
+	client, ok := c.clients[id]
+	c.mu.Unlock()
+	if ok {
+		return client, nil
+	}
+
+	req, err := c.client.NewRequest(ctx, http.MethodGet, "v2/databases/"+id+"/ca", nil)
+	if err != nil {
+		return nil, err
+	}
+	var root struct {
+		CA struct {
+			// Certificate is base64 encoded PEM.
+			Certificate string `json:"certificate"`
+		} `json:"ca"`
+	}
+	if _, err := c.client.Do(ctx, req, &root); err != nil {
+		return nil, err
+	}
+	pem, err := base64.StdEncoding.DecodeString(root.CA.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate in the CA of database %s", id)
+	}
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	c.mu.Lock()
+	c.clients[id] = client
+	c.mu.Unlock()
+	return client, nil
+}
+
+// scrapeDatabaseMetrics gets and parses the metrics of the endpoint at host.
+func scrapeDatabaseMetrics(ctx context.Context, client *http.Client, host, username, password string) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://"+host+"/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(username, password)
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
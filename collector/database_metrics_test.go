@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newTestDatabaseEndpoint starts a TLS server serving the metrics to requests with the credentials,
+// and returns the fixtures of a database cluster with it as its metrics endpoint.
+func newTestDatabaseEndpoint(t *testing.T, username, password, metrics string) map[string]string {
+	endpoint := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, metrics)
+	}))
+	t.Cleanup(endpoint.Close)
+
+	host, port, err := net.SplitHostPort(endpoint.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: endpoint.Certificate().Raw})
+
+	return map[string]string{
+		"/v2/databases": `{"databases":[
+			{"id":"d1","name":"main","engine":"pg","region":"nyc1","status":"online",
+			 "metrics_endpoints":[{"host":"` + host + `","port":` + port + `}]}
+		]}`,
+		"/v2/databases/d1/ca":               `{"ca":{"certificate":"` + base64.StdEncoding.EncodeToString(ca) + `"}}`,
+		"/v2/databases/metrics/credentials": `{"credentials":{"basic_auth_username":"api-user","basic_auth_password":"api-secret"}}`,
+	}
+}
+
+// gatherUndescribed gathers the collector like gather, but with a registry that isn't pedantic,
+// which accepts the metrics the collector didn't describe.
+func gatherUndescribed(t *testing.T, c prometheus.Collector) []*dto.MetricFamily {
+	r := prometheus.NewRegistry()
+	if err := r.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mfs
+}
+
+const testDatabaseMetrics = `# HELP cpu_usage_idle Percentage of idle CPU.
+# TYPE cpu_usage_idle gauge
+cpu_usage_idle{cpu="cpu-total"} 93.5
+# HELP pg_stat_database_numbackends Number of backends connected to the database.
+# TYPE pg_stat_database_numbackends gauge
+pg_stat_database_numbackends{datname="defaultdb"} 4
+# HELP net_bytes_recv Bytes received.
+# TYPE net_bytes_recv counter
+net_bytes_recv 1024
+`
+
+func TestDatabaseMetricsCollector(t *testing.T) {
+	api := newTestAPI(t, newTestDatabaseEndpoint(t, "api-user", "api-secret", testDatabaseMetrics))
+	c := testConfig(api.client(t))
+	c.DatabaseMetrics = true
+	mfs := gatherUndescribed(t, newTestCollector(t, "database_metrics", c))
+
+	endpoint := []string{"database_id=d1", "database_name=main", "endpoint=127.0.0.1"}
+	assertMetric(t, mfs, 93.5, "digitalocean_database_cpu_usage_idle", append(endpoint, "cpu=cpu-total")...)
+	assertMetric(t, mfs, 4, "digitalocean_database_pg_stat_database_numbackends", append(endpoint, "datname=defaultdb")...)
+	assertMetric(t, mfs, 1024, "digitalocean_database_net_bytes_recv", endpoint...)
+	if _, ok := metricValue(mfs, "digitalocean_collector_last_success_timestamp_seconds", "collector=database_metrics"); !ok {
+		t.Error("scraping the endpoint failed")
+	}
+}
+
+func TestDatabaseMetricsCollectorCredentials(t *testing.T) {
+	api := newTestAPI(t, newTestDatabaseEndpoint(t, "user", "secret", testDatabaseMetrics))
+	c := testConfig(api.client(t))
+	c.DatabaseMetrics = true
+	c.DatabaseMetricsUsername = "user"
+	c.DatabaseMetricsPassword = "secret"
+	col := newTestCollector(t, "database_metrics", c)
+	gatherUndescribed(t, col)
+	mfs := gatherUndescribed(t, col)
+
+	assertMetric(t, mfs, 4, "digitalocean_database_pg_stat_database_numbackends", "database_id=d1", "database_name=main", "endpoint=127.0.0.1", "datname=defaultdb")
+	if n := api.requested("/v2/databases/metrics/credentials"); n != 0 {
+		t.Errorf("credentials were got %d times, want 0", n)
+	}
+	// The CA certificate doesn't change, it's only got once.
+	if n := api.requested("/v2/databases/d1/ca"); n != 1 {
+		t.Errorf("the CA certificate was got %d times, want 1", n)
+	}
+}
+
+func TestDatabaseMetricsCollectorDisabled(t *testing.T) {
+	api := newTestAPI(t, nil)
+	if _, ok := Collectors(testConfig(api.client(t)))["database_metrics"]; ok {
+		t.Error("the database metrics collector was built without DatabaseMetrics")
+	}
+}
+
+func TestDatabaseMetricsCollectorUnauthorized(t *testing.T) {
+	api := newTestAPI(t, newTestDatabaseEndpoint(t, "user", "secret", testDatabaseMetrics))
+	c := testConfig(api.client(t))
+	c.DatabaseMetrics = true
+	mfs := gatherUndescribed(t, newTestCollector(t, "database_metrics", c))
+
+	assertNoMetric(t, mfs, "digitalocean_database_net_bytes_recv", "database_id=d1", "database_name=main", "endpoint=127.0.0.1")
+	assertNoMetric(t, mfs, "digitalocean_collector_last_success_timestamp_seconds", "collector=database_metrics")
+}
@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"testing"
+)
+
+var databaseFixtures = map[string]string{
+	"/v2/databases": `{"databases":[
+		{"id":"d1","name":"main","engine":"pg","version":"16","size":"db-s-2vcpu-4gb","region":"nyc1","num_nodes":2,"status":"online",
+		 "maintenance_window":{"day":"tuesday","hour":"03:00:00","pending":true}},
+		{"id":"d2","name":"cache","engine":"redis","version":"7","size":"db-s-1vcpu-1gb","region":"fra1","num_nodes":1,"status":"online",
+		 "maintenance_window":{"day":"sunday","hour":"22:00:00","pending":false}},
+		{"id":"d3","name":"new","engine":"mysql","version":"8","size":"db-s-1vcpu-1gb","region":"nyc1","num_nodes":1,"status":"creating"}
+	]}`,
+	"/v2/databases/d1/replicas": `{"replicas":[
+		{"name":"main-replica-1","region":"nyc1","status":"online"},
+		{"name":"main-replica-2","region":"sfo3","status":"creating"}
+	]}`,
+	"/v2/databases/d1/pools":    `{"pools":[{"name":"app"}]}`,
+	"/v2/databases/d1/users":    `{"users":[{"name":"doadmin"},{"name":"app"},{"name":"reporting"}]}`,
+	"/v2/databases/d1/dbs":      `{"dbs":[{"name":"defaultdb"},{"name":"app"}]}`,
+	"/v2/databases/d2/replicas": `{"replicas":[]}`,
+	"/v2/databases/d3/replicas": `{"replicas":[]}`,
+	"/v2/databases/d3/users":    `{"users":[{"name":"doadmin"}]}`,
+	"/v2/databases/d3/dbs":      `{"dbs":[]}`,
+}
+
+func TestDatabaseCollector(t *testing.T) {
+	api := newTestAPI(t, databaseFixtures)
+	mfs := gather(t, newTestCollector(t, "database", testConfig(api.client(t))))
+
+	main := []string{"id=d1", "name=main", "region=nyc1"}
+	cache := []string{"id=d2", "name=cache", "region=fra1"}
+
+	assertMetric(t, mfs, 1, "digitalocean_database_info", append(main, "engine=pg", "version=16", "size=db-s-2vcpu-4gb")...)
+	assertMetric(t, mfs, 1, "digitalocean_database_up", main...)
+	assertMetric(t, mfs, 1, "digitalocean_database_up", cache...)
+	assertMetric(t, mfs, 0, "digitalocean_database_up", "id=d3", "name=new", "region=nyc1")
+	assertMetric(t, mfs, 2, "digitalocean_database_nodes", main...)
+	assertMetric(t, mfs, 1, "digitalocean_database_maintenance_pending", append(main, "day=tuesday", "hour=03:00:00")...)
+	assertMetric(t, mfs, 0, "digitalocean_database_maintenance_pending", append(cache, "day=sunday", "hour=22:00:00")...)
+
+	// The replicas and pools are only listed with DatabaseIncludeDetails.
+	assertNoMetric(t, mfs, "digitalocean_database_replica_count", main...)
+	if n := api.requested("/v2/databases/d1/replicas"); n != 0 {
+		t.Errorf("replicas were listed %d times, want 0", n)
+	}
+}
+
+func TestDatabaseCollectorIncludeDetails(t *testing.T) {
+	api := newTestAPI(t, databaseFixtures)
+	c := testConfig(api.client(t))
+	c.DatabaseIncludeDetails = true
+	mfs := gather(t, newTestCollector(t, "database", c))
+
+	main := []string{"id=d1", "name=main", "region=nyc1"}
+	cache := []string{"id=d2", "name=cache", "region=fra1"}
+
+	assertMetric(t, mfs, 2, "digitalocean_database_replica_count", main...)
+	assertMetric(t, mfs, 1, "digitalocean_database_replica_up", "database_id=d1", "database_name=main", "name=main-replica-1", "region=nyc1")
+	assertMetric(t, mfs, 0, "digitalocean_database_replica_up", "database_id=d1", "database_name=main", "name=main-replica-2", "region=sfo3")
+	// Clusters without replicas have a count of 0.
+	assertMetric(t, mfs, 0, "digitalocean_database_replica_count", cache...)
+	assertMetric(t, mfs, 1, "digitalocean_database_connection_pools", main...)
+	// Redis clusters have no connection pools.
+	assertNoMetric(t, mfs, "digitalocean_database_connection_pools", cache...)
+	if n := api.requested("/v2/databases/d2/pools"); n != 0 {
+		t.Errorf("pools of the redis cluster were listed %d times, want 0", n)
+	}
+
+	assertMetric(t, mfs, 3, "digitalocean_database_user_count", main...)
+	assertMetric(t, mfs, 2, "digitalocean_database_db_count", main...)
+	assertMetric(t, mfs, 1, "digitalocean_database_user_count", "id=d3", "name=new", "region=nyc1")
+	assertMetric(t, mfs, 0, "digitalocean_database_db_count", "id=d3", "name=new", "region=nyc1")
+	// Redis clusters have neither users nor databases.
+	assertNoMetric(t, mfs, "digitalocean_database_user_count", cache...)
+	for _, path := range []string{"/v2/databases/d2/users", "/v2/databases/d2/dbs"} {
+		if n := api.requested(path); n != 0 {
+			t.Errorf("%s of the redis cluster was requested %d times, want 0", path, n)
+		}
+	}
+	if _, ok := metricValue(mfs, "digitalocean_collector_last_success_timestamp_seconds", "collector=database"); !ok {
+		t.Error("listing the details failed")
+	}
+}
+
+func TestDatabaseCollectorRegions(t *testing.T) {
+	api := newTestAPI(t, databaseFixtures)
+	c := testConfig(api.client(t))
+	c.Regions = []string{"fra1"}
+	mfs := gather(t, newTestCollector(t, "database", c))
+
+	assertNoMetric(t, mfs, "digitalocean_database_up", "id=d1", "name=main", "region=nyc1")
+	assertMetric(t, mfs, 1, "digitalocean_database_up", "id=d2", "name=cache", "region=fra1")
+}
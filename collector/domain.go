@@ -3,6 +3,7 @@ package collector
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/digitalocean/godo"
@@ -11,26 +12,43 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("domain", func(c Config) prometheus.Collector {
+		return NewDomainCollector(c)
+	})
+}
+
 // DomainCollector collects metrics about all images created by the user.
 type DomainCollector struct {
-	logger  log.Logger
-	client  *godo.Client
-	timeout time.Duration
+	logger      log.Logger
+	client      *godo.Client
+	timeout     time.Duration
+	perPage     int
+	recordTypes bool
+	*lastSuccess
+	pages *pageCounter
 
 	DomainRecordPort     *prometheus.Desc
 	DomainRecordPriority *prometheus.Desc
 	DomainRecordWeight   *prometheus.Desc
 	DomainTTL            *prometheus.Desc
+	ByTLD                *prometheus.Desc
+	RecordsByType        *prometheus.Desc
 }
 
-// NewDomainCollector returns a new DomainCollector.
-func NewDomainCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *DomainCollector {
+// NewDomainCollector returns a new DomainCollector built from the Config.
+// With DomainIncludeRecords the domain's records are also counted by their type.
+func NewDomainCollector(c Config) *DomainCollector {
 	recordLabels := []string{"id", "name", "type", "data"}
 
 	return &DomainCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		recordTypes: c.DomainIncludeRecords,
+		lastSuccess: newLastSuccess("domain"),
+		pages:       newPageCounter("domain"),
 
 		DomainRecordPort: prometheus.NewDesc(
 			"digitalocean_domain_record_port",
@@ -52,6 +70,16 @@ func NewDomainCollector(logger log.Logger, client *godo.Client, timeout time.Dur
 			"Seconds that clients can cache queried information before a refresh should be requested",
 			[]string{"name"}, nil,
 		),
+		ByTLD: prometheus.NewDesc(
+			"digitalocean_domains_by_tld",
+			"Number of domains by their top-level domain",
+			[]string{"tld"}, nil,
+		),
+		RecordsByType: prometheus.NewDesc(
+			"digitalocean_domain_records_by_type",
+			"Number of the domain's records by their type",
+			[]string{"domain", "type"}, nil,
+		),
 	}
 }
 
@@ -62,22 +90,31 @@ func (c *DomainCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.DomainRecordPriority
 	ch <- c.DomainRecordWeight
 	ch <- c.DomainTTL
+	ch <- c.ByTLD
+	ch <- c.RecordsByType
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *DomainCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
 
-	domains, _, err := c.client.Domains.List(ctx, nil)
+	domains, err := listDomains(ctx, c.client, c.perPage)
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't list domains",
 			"err", err,
 		)
+		c.lastSuccess.collect(ch, false)
 		return
 	}
 
+	succeeded := true
+	byTLD := map[string]int{}
 	for _, domain := range domains {
 		ch <- prometheus.MustNewConstMetric(
 			c.DomainTTL,
@@ -85,11 +122,26 @@ func (c *DomainCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(domain.TTL),
 			domain.Name,
 		)
+		byTLD[domain.Name[strings.LastIndex(domain.Name, ".")+1:]]++
+
+		// The records share the collector's timeout with the domains.
+		var records []godo.DomainRecord
+		err := paginate(ctx, c.perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+			page, resp, err := c.client.Domains.Records(ctx, domain.Name, opt)
+			records = append(records, page...)
+			return resp, err
+		})
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list records of domain",
+				"domain", domain.Name,
+				"err", err,
+			)
+			succeeded = false
+		} else if c.recordTypes {
+			c.collectRecordTypes(ch, domain.Name, records)
+		}
 
-		//ctx, cancel := context.WithTimeout(ctx, c.timeout)
-		//cancel()
-		ctx := context.TODO()
-		records, _, _ := c.client.Domains.Records(ctx, domain.Name, nil)
 		for _, record := range records {
 			ch <- prometheus.MustNewConstMetric(
 				c.DomainRecordPort,
@@ -111,4 +163,42 @@ func (c *DomainCollector) Collect(ch chan<- prometheus.Metric) {
 			)
 		}
 	}
+
+	for tld, count := range byTLD {
+		ch <- prometheus.MustNewConstMetric(
+			c.ByTLD,
+			prometheus.GaugeValue,
+			float64(count),
+			tld,
+		)
+	}
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+// collectRecordTypes sends the number of the domain's records by type.
+// Domains without records have no types to count.
+func (c *DomainCollector) collectRecordTypes(ch chan<- prometheus.Metric, domain string, records []godo.DomainRecord) {
+	byType := map[string]int{}
+	for _, record := range records {
+		byType[record.Type]++
+	}
+	for recordType, count := range byType {
+		ch <- prometheus.MustNewConstMetric(
+			c.RecordsByType,
+			prometheus.GaugeValue,
+			float64(count),
+			domain, recordType,
+		)
+	}
+}
+
+func listDomains(ctx context.Context, client *godo.Client, perPage int) ([]godo.Domain, error) {
+	var domains []godo.Domain
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.Domains.List(ctx, opt)
+		domains = append(domains, page...)
+		return resp, err
+	})
+	return domains, err
 }
@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"testing"
+)
+
+var domainFixtures = map[string]string{
+	"/v2/domains": `{"domains":[
+		{"name":"example.com","ttl":1800},
+		{"name":"example.org","ttl":3600}
+	],"links":{}}`,
+	"/v2/domains/example.com/records": `{"domain_records":[
+		{"id":1,"type":"A","name":"@","data":"203.0.113.1"},
+		{"id":2,"type":"A","name":"www","data":"203.0.113.1"},
+		{"id":3,"type":"MX","name":"@","data":"mx.example.com","priority":10}
+	],"links":{}}`,
+	"/v2/domains/example.org/records": `{"domain_records":[],"links":{}}`,
+}
+
+func TestDomainCollector(t *testing.T) {
+	api := newTestAPI(t, domainFixtures)
+	mfs := gather(t, newTestCollector(t, "domain", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 1800, "digitalocean_domain_ttl_seconds", "name=example.com")
+	assertMetric(t, mfs, 1, "digitalocean_domains_by_tld", "tld=com")
+	assertMetric(t, mfs, 1, "digitalocean_domains_by_tld", "tld=org")
+
+	// The records are always listed, only their types are counted with DomainIncludeRecords.
+	assertMetric(t, mfs, 10, "digitalocean_domain_record_priority", "id=3", "name=@", "type=MX", "data=mx.example.com")
+	assertNoMetric(t, mfs, "digitalocean_domain_records_by_type", "domain=example.com", "type=A")
+}
+
+func TestDomainCollectorIncludeRecords(t *testing.T) {
+	api := newTestAPI(t, domainFixtures)
+	c := testConfig(api.client(t))
+	c.DomainIncludeRecords = true
+	mfs := gather(t, newTestCollector(t, "domain", c))
+
+	assertMetric(t, mfs, 2, "digitalocean_domain_records_by_type", "domain=example.com", "type=A")
+	assertMetric(t, mfs, 1, "digitalocean_domain_records_by_type", "domain=example.com", "type=MX")
+	assertNoMetric(t, mfs, "digitalocean_domain_records_by_type", "domain=example.org", "type=A")
+	assertMetric(t, mfs, 10, "digitalocean_domain_record_priority", "id=3", "name=@", "type=MX", "data=mx.example.com")
+}
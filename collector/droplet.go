@@ -3,6 +3,10 @@ package collector
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/digitalocean/godo"
@@ -11,29 +15,101 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("droplet", func(c Config) prometheus.Collector {
+		return NewDropletCollector(c)
+	})
+}
+
 // DropletCollector collects metrics about all droplets.
 type DropletCollector struct {
-	logger  log.Logger
-	client  *godo.Client
-	timeout time.Duration
+	logger    log.Logger
+	client    *godo.Client
+	timeout   time.Duration
+	perPage   int
+	regions   []string
+	cache     *ScrapeCache
+	tagKeys   []string
+	maxTags   int
+	labelKeys []string
+	filter    DropletFilter
+	required  []string
+	infoOnly  bool
+	recent    time.Duration
+	backups   bool
+	neighbors bool
+	*lastSuccess
+	pages       *pageCounter
+	empty       *emptyGuard
+	transitions *statusTransitions
 
+	Info         *prometheus.Desc
 	Up           *prometheus.Desc
 	CPUs         *prometheus.Desc
 	Memory       *prometheus.Desc
 	Disk         *prometheus.Desc
 	PriceHourly  *prometheus.Desc
 	PriceMonthly *prometheus.Desc
+	Image        *prometheus.Desc
+	Locked       *prometheus.Desc
+	Migrating    *prometheus.Desc
+
+	StatusTransitions *prometheus.Desc
+
+	MissingRequiredTags *prometheus.Desc
+
+	BackupCount   *prometheus.Desc
+	SnapshotCount *prometheus.Desc
+
+	NeighborGroupSize *prometheus.Desc
+
+	WithBackups    *prometheus.Desc
+	WithMonitoring *prometheus.Desc
+	WithIPv6       *prometheus.Desc
+	GPUCount       *prometheus.Desc
+
+	NewestCreated   *prometheus.Desc
+	CreatedRecently *prometheus.Desc
+	Created24h      *prometheus.Desc
+	ByTag           *prometheus.Desc
+	TagsTruncated   *prometheus.Desc
+
+	NotFound *prometheus.Desc
+
+	Labels *prometheus.Desc
 }
 
-// NewDropletCollector returns a new DropletCollector.
-func NewDropletCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *DropletCollector {
+// NewDropletCollector returns a new DropletCollector built from the Config.
+// The droplets are got from the cache, which only gets the configured droplet IDs if there are any.
+func NewDropletCollector(c Config) *DropletCollector {
 	labels := []string{"id", "name", "region"}
 
 	return &DropletCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		cache:       c.Cache,
+		tagKeys:     c.DropletTagKeys,
+		maxTags:     c.DropletMaxTagCardinality,
+		labelKeys:   c.DropletLabelKeys,
+		filter:      c.DropletFilter,
+		required:    c.DropletRequiredTags,
+		infoOnly:    c.DropletInfoOnly,
+		recent:      c.DropletRecentWindow,
+		backups:     c.DropletBackupCounts,
+		neighbors:   c.DropletNeighbors,
+		lastSuccess: newLastSuccess("droplet"),
+		pages:       newPageCounter("droplet"),
+		empty:       newEmptyGuard("droplet", c.RejectEmpty),
+		transitions: &statusTransitions{status: map[int]string{}, count: map[int]uint64{}},
 
+		Info: prometheus.NewDesc(
+			"digitalocean_droplet_info",
+			"A metric with a constant '1' value labeled by the droplet's attributes",
+			append(labels, "size", "status", "distribution", "image_name", "source_type", "is_gpu"), nil,
+		),
 		Up: prometheus.NewDesc(
 			"digitalocean_droplet_up",
 			"If 1 the droplet is up and running, 0 otherwise",
@@ -64,39 +140,245 @@ func NewDropletCollector(logger log.Logger, client *godo.Client, timeout time.Du
 			"Price of the Droplet billed monthly in dollars",
 			labels, nil,
 		),
+		Image: prometheus.NewDesc(
+			"digitalocean_droplet_image",
+			"Information about the image the Droplet was created from",
+			append(labels, "distribution", "image_name", "source_type"), nil,
+		),
+		Locked: prometheus.NewDesc(
+			"digitalocean_droplet_locked",
+			"If 1 the droplet is locked by a running operation, 0 otherwise",
+			labels, nil,
+		),
+		Migrating: prometheus.NewDesc(
+			"digitalocean_droplet_migrating",
+			"If 1 the droplet has a migrate action in progress, 0 otherwise",
+			labels, nil,
+		),
+		StatusTransitions: prometheus.NewDesc(
+			"digitalocean_droplet_status_transitions_total",
+			"Total number of changes of the droplet's status between collections since the exporter started",
+			labels, nil,
+		),
+		MissingRequiredTags: prometheus.NewDesc(
+			"digitalocean_droplet_missing_required_tags",
+			"If 1 the droplet lacks at least one of the required tags, 0 otherwise",
+			labels, nil,
+		),
+		BackupCount: prometheus.NewDesc(
+			"digitalocean_droplet_backup_count",
+			"Number of backups stored of the droplet",
+			labels, nil,
+		),
+		SnapshotCount: prometheus.NewDesc(
+			"digitalocean_droplet_snapshot_count",
+			"Number of snapshots taken of the droplet",
+			labels, nil,
+		),
+		NeighborGroupSize: prometheus.NewDesc(
+			"digitalocean_droplet_neighbor_group_size",
+			"Number of the account's droplets on the same physical host as the droplet, including itself",
+			labels, nil,
+		),
+		WithBackups: prometheus.NewDesc(
+			"digitalocean_droplets_with_backups",
+			"Number of droplets with backups enabled",
+			nil, nil,
+		),
+		WithMonitoring: prometheus.NewDesc(
+			"digitalocean_droplets_with_monitoring",
+			"Number of droplets with monitoring enabled",
+			nil, nil,
+		),
+		WithIPv6: prometheus.NewDesc(
+			"digitalocean_droplets_with_ipv6",
+			"Number of droplets with IPv6 enabled",
+			nil, nil,
+		),
+		GPUCount: prometheus.NewDesc(
+			"digitalocean_droplets_gpu_count",
+			"Number of droplets with a GPU size, whose slug starts with gpu-",
+			nil, nil,
+		),
+		NewestCreated: prometheus.NewDesc(
+			"digitalocean_droplet_newest_created_timestamp_seconds",
+			"Unix timestamp of the creation of the most recently created droplet",
+			nil, nil,
+		),
+		CreatedRecently: prometheus.NewDesc(
+			"digitalocean_droplets_created_recently",
+			fmt.Sprintf("Number of droplets created within the last %s", c.DropletRecentWindow),
+			nil, nil,
+		),
+		Created24h: newCreated24hDesc("droplet"),
+		ByTag: prometheus.NewDesc(
+			"digitalocean_droplets_by_tag",
+			"Number of droplets by the key and value of their key:value tags",
+			[]string{"tag_key", "tag_value"}, nil,
+		),
+		TagsTruncated: prometheus.NewDesc(
+			"digitalocean_droplet_tags_truncated",
+			fmt.Sprintf("If 1 there were more than %d series of digitalocean_droplets_by_tag and the rest were dropped, 0 otherwise", c.DropletMaxTagCardinality),
+			nil, nil,
+		),
+		Labels: prometheus.NewDesc(
+			"digitalocean_droplet_labels",
+			"A metric with a constant '1' value labeled by the values of the droplet's key:value tags",
+			append(labels, c.DropletLabelKeys...), nil,
+		),
+		NotFound: prometheus.NewDesc(
+			"digitalocean_droplet_not_found",
+			"Number of the configured droplet IDs that weren't found",
+			nil, nil,
+		),
 	}
 }
 
 // Describe sends the super-set of all possible descriptors of metrics
 // collected by this Collector.
 func (c *DropletCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Info
 	ch <- c.Up
 	ch <- c.CPUs
 	ch <- c.Memory
 	ch <- c.Disk
 	ch <- c.PriceHourly
 	ch <- c.PriceMonthly
+	ch <- c.Image
+	ch <- c.Locked
+	ch <- c.Migrating
+	ch <- c.StatusTransitions
+	ch <- c.MissingRequiredTags
+	ch <- c.BackupCount
+	ch <- c.SnapshotCount
+	ch <- c.NeighborGroupSize
+	ch <- c.WithBackups
+	ch <- c.WithMonitoring
+	ch <- c.WithIPv6
+	ch <- c.GPUCount
+	ch <- c.NewestCreated
+	ch <- c.CreatedRecently
+	ch <- c.Created24h
+	ch <- c.ByTag
+	ch <- c.TagsTruncated
+	ch <- c.NotFound
+	ch <- c.Labels
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+	ch <- c.empty.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *DropletCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
-	droplets, _, err := c.client.Droplets.List(ctx, nil)
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	var droplets []godo.Droplet
+	scope, err := c.cache.droplets(ctx)
+	if err == nil {
+		droplets = scope.droplets
+		if scope.ids != nil {
+			for _, id := range scope.notFound {
+				level.Warn(c.logger).Log(
+					"msg", "droplet not found",
+					"droplet", id,
+				)
+			}
+			ch <- prometheus.MustNewConstMetric(c.NotFound, prometheus.GaugeValue, float64(len(scope.notFound)))
+		}
+	}
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't list droplets",
 			"err", err,
 		)
+	} else {
+		droplets = c.empty.filter(droplets, len(droplets)).([]godo.Droplet)
+	}
+	c.empty.collect(ch)
+
+	var transitions map[int]uint64
+	if err == nil && !c.infoOnly {
+		transitions = c.transitions.update(droplets)
+	}
+
+	// Migrations are only exposed per droplet, which info only mode doesn't.
+	var migrating map[int]bool
+	var actionsErr error
+	if !c.infoOnly {
+		migrating, actionsErr = c.migratingDroplets(ctx, droplets)
+		if actionsErr != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list actions",
+				"err", actionsErr,
+			)
+		}
 	}
 
+	var imagesErr, neighborsErr error
+	features := map[string]int{}
+	var newest time.Time
+	var recent, created24h, gpus int
+	byTag := map[[2]string]int{}
 	for _, droplet := range droplets {
+		if !inRegions(c.regions, droplet.Region) || c.filter.excludes(droplet) {
+			continue
+		}
+
+		for _, feature := range droplet.Features {
+			features[feature]++
+		}
+		gpu := isGPU(droplet)
+		if gpu {
+			gpus++
+		}
+		for _, tag := range droplet.Tags {
+			if key, value, ok := c.splitTag(tag); ok {
+				byTag[[2]string{key, value}]++
+			}
+		}
+		if created, err := time.Parse(time.RFC3339, droplet.Created); err == nil {
+			if created.After(newest) {
+				newest = created
+			}
+			if time.Since(created) <= c.recent {
+				recent++
+			}
+			if createdWithin24h(created) {
+				created24h++
+			}
+		}
+
 		labels := []string{
 			fmt.Sprintf("%d", droplet.ID),
 			droplet.Name,
 			droplet.Region.Slug,
 		}
 
+		if len(c.labelKeys) > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				c.Labels,
+				prometheus.GaugeValue,
+				1.0,
+				append(labels, tagLabels(droplet.Tags, c.labelKeys)...)...,
+			)
+		}
+
+		var distribution, imageName string
+		if droplet.Image != nil {
+			distribution, imageName = droplet.Image.Distribution, droplet.Image.Name
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Info,
+			prometheus.GaugeValue,
+			1.0,
+			append(labels, droplet.SizeSlug, droplet.Status, distribution, imageName, imageSourceType(droplet.Image), strconv.FormatBool(gpu))...,
+		)
+		if c.infoOnly {
+			continue
+		}
+
 		var active float64
 		if droplet.Status == "active" {
 			active = 1.0
@@ -137,5 +419,313 @@ func (c *DropletCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(droplet.Size.PriceMonthly),
 			labels...,
 		)
+
+		var locked float64
+		if droplet.Locked {
+			locked = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Locked,
+			prometheus.GaugeValue,
+			locked,
+			labels...,
+		)
+
+		if migrating != nil {
+			var active float64
+			if migrating[droplet.ID] {
+				active = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.Migrating,
+				prometheus.GaugeValue,
+				active,
+				labels...,
+			)
+		}
+
+		if transitions != nil {
+			ch <- prometheus.MustNewConstMetric(
+				c.StatusTransitions,
+				prometheus.CounterValue,
+				float64(transitions[droplet.ID]),
+				labels...,
+			)
+		}
+
+		if len(c.required) > 0 {
+			var missing float64
+			if !hasTags(droplet.Tags, c.required) {
+				missing = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.MissingRequiredTags,
+				prometheus.GaugeValue,
+				missing,
+				labels...,
+			)
+		}
+
+		if droplet.Image != nil {
+			ch <- prometheus.MustNewConstMetric(
+				c.Image,
+				prometheus.GaugeValue,
+				1.0,
+				append(labels, droplet.Image.Distribution, droplet.Image.Name, imageSourceType(droplet.Image))...,
+			)
+		}
+
+		// After the first error, most likely the timeout, the remaining droplets aren't tried.
+		if c.backups && imagesErr == nil {
+			var backups, snapshots int
+			backups, snapshots, imagesErr = c.imageCounts(ctx, droplet.ID)
+			if imagesErr != nil {
+				level.Warn(c.logger).Log(
+					"msg", "can't list backups and snapshots of droplet",
+					"droplet", droplet.ID,
+					"err", imagesErr,
+				)
+			} else {
+				ch <- prometheus.MustNewConstMetric(c.BackupCount, prometheus.GaugeValue, float64(backups), labels...)
+				ch <- prometheus.MustNewConstMetric(c.SnapshotCount, prometheus.GaugeValue, float64(snapshots), labels...)
+			}
+		}
+
+		// Droplets without neighbors get an empty list, so their group is only themselves.
+		if c.neighbors && neighborsErr == nil {
+			var neighbors []godo.Droplet
+			neighbors, _, neighborsErr = c.client.Droplets.Neighbors(ctx, droplet.ID)
+			if neighborsErr != nil {
+				level.Warn(c.logger).Log(
+					"msg", "can't list neighbors of droplet",
+					"droplet", droplet.ID,
+					"err", neighborsErr,
+				)
+			} else {
+				ch <- prometheus.MustNewConstMetric(c.NeighborGroupSize, prometheus.GaugeValue, float64(len(neighbors)+1), labels...)
+			}
+		}
+	}
+	c.lastSuccess.collect(ch, err == nil && actionsErr == nil && imagesErr == nil && neighborsErr == nil)
+
+	// Without the list of droplets the counts would be 0, not unknown.
+	if err == nil {
+		ch <- prometheus.MustNewConstMetric(c.WithBackups, prometheus.GaugeValue, float64(features["backups"]))
+		ch <- prometheus.MustNewConstMetric(c.WithMonitoring, prometheus.GaugeValue, float64(features["monitoring"]))
+		ch <- prometheus.MustNewConstMetric(c.WithIPv6, prometheus.GaugeValue, float64(features["ipv6"]))
+		ch <- prometheus.MustNewConstMetric(c.GPUCount, prometheus.GaugeValue, float64(gpus))
+		ch <- prometheus.MustNewConstMetric(c.CreatedRecently, prometheus.GaugeValue, float64(recent))
+		ch <- prometheus.MustNewConstMetric(c.Created24h, prometheus.GaugeValue, float64(created24h))
+	}
+	if err == nil && !newest.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.NewestCreated, prometheus.GaugeValue, float64(newest.Unix()))
+	}
+	c.collectByTag(ch, byTag)
+}
+
+// collectByTag sends the number of droplets by tag. With a maximum tag cardinality
+// only the first tags sorted by key and value are sent, so that the same series are kept.
+func (c *DropletCollector) collectByTag(ch chan<- prometheus.Metric, byTag map[[2]string]int) {
+	tags := make([][2]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i][0] != tags[j][0] {
+			return tags[i][0] < tags[j][0]
+		}
+		return tags[i][1] < tags[j][1]
+	})
+
+	if c.maxTags > 0 {
+		var truncated float64
+		if len(tags) > c.maxTags {
+			level.Warn(c.logger).Log(
+				"msg", "too many droplet tags, truncating",
+				"tags", len(tags),
+				"max", c.maxTags,
+			)
+			tags = tags[:c.maxTags]
+			truncated = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.TagsTruncated, prometheus.GaugeValue, truncated)
+	}
+
+	for _, tag := range tags {
+		ch <- prometheus.MustNewConstMetric(c.ByTag, prometheus.GaugeValue, float64(byTag[tag]), tag[0], tag[1])
 	}
 }
+
+// splitTag splits a key:value tag if its key is one of the collector's tag keys.
+func (c *DropletCollector) splitTag(tag string) (string, string, bool) {
+	return splitKeyTag(tag, c.tagKeys)
+}
+
+// splitKeyTag splits a key:value tag if its key is one of the keys.
+func splitKeyTag(tag string, keys []string) (string, string, bool) {
+	i := strings.Index(tag, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	key, value := tag[:i], tag[i+1:]
+	for _, k := range keys {
+		if k == key {
+			return key, value, true
+		}
+	}
+	return "", "", false
+}
+
+// tagLabels returns the values of the key:value tags for each of the keys.
+// Keys without a tag have an empty value, several values of the same key are joined
+// sorted by commas. Tags without one of the keys or without a value are ignored.
+func tagLabels(tags []string, keys []string) []string {
+	byKey := map[string][]string{}
+	for _, tag := range tags {
+		if key, value, ok := splitKeyTag(tag, keys); ok && value != "" {
+			byKey[key] = append(byKey[key], value)
+		}
+	}
+
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		sort.Strings(byKey[key])
+		values[i] = strings.Join(byKey[key], ",")
+	}
+	return values
+}
+
+// isGPU reports whether the droplet has a GPU size. The API doesn't say so directly,
+// so it's derived from the size slug: GPU sizes like gpu-h100x1-80gb start with gpu-,
+// while slugs starting with g- are General Purpose sizes without a GPU.
+func isGPU(droplet godo.Droplet) bool {
+	slug := droplet.SizeSlug
+	if slug == "" && droplet.Size != nil {
+		slug = droplet.Size.Slug
+	}
+	return strings.HasPrefix(slug, "gpu-")
+}
+
+// hasTags reports whether tags has all required tags,
+// either as tag or as key of a key:value tag like "team" of "team:a".
+func hasTags(tags []string, required []string) bool {
+	for _, r := range required {
+		var found bool
+		for _, tag := range tags {
+			if tag == r || strings.HasPrefix(tag, r+":") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// statusTransitions counts the changes of droplets' statuses between collections.
+type statusTransitions struct {
+	mu     sync.Mutex
+	status map[int]string
+	count  map[int]uint64
+}
+
+// update records the droplets' statuses and returns their number of transitions.
+// Droplets that don't exist anymore are forgotten.
+func (t *statusTransitions) update(droplets []godo.Droplet) map[int]uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := make(map[int]string, len(droplets))
+	count := make(map[int]uint64, len(droplets))
+	for _, droplet := range droplets {
+		n := t.count[droplet.ID]
+		if previous, ok := t.status[droplet.ID]; ok && previous != droplet.Status {
+			n++
+		}
+		status[droplet.ID] = droplet.Status
+		count[droplet.ID] = n
+	}
+	// The maps are replaced, never modified, so count can be returned.
+	t.status, t.count = status, count
+	return count
+}
+
+// imageSourceType returns how the droplet was created from the image:
+// distribution, snapshot, backup or custom. Base images are DigitalOcean's distributions.
+func imageSourceType(image *godo.Image) string {
+	if image == nil {
+		return ""
+	}
+	if image.Type == "base" {
+		return "distribution"
+	}
+	return image.Type
+}
+
+// migratingDroplets returns the IDs of the droplets with a migrate action in progress.
+// Droplets are locked while an action is in progress, so only the locked droplets' actions are listed,
+// which is one more API call per locked droplet.
+func (c *DropletCollector) migratingDroplets(ctx context.Context, droplets []godo.Droplet) (map[int]bool, error) {
+	migrating := map[int]bool{}
+	for _, droplet := range droplets {
+		if !droplet.Locked || !inRegions(c.regions, droplet.Region) || c.filter.excludes(droplet) {
+			continue
+		}
+
+		// The API returns the newest actions first, so the actions in progress come before
+		// the completed ones, however long ago they were started.
+		id := droplet.ID
+		err := paginate(ctx, c.perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+			page, resp, err := c.client.Droplets.Actions(ctx, id, opt)
+			if err != nil {
+				return resp, err
+			}
+
+			for _, action := range page {
+				if action.Status != "in-progress" {
+					return resp, errStopPaging
+				}
+				if action.Type == "migrate" {
+					migrating[id] = true
+					return resp, errStopPaging
+				}
+			}
+			return resp, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return migrating, nil
+}
+
+// imageCounts returns the number of backups and snapshots of the droplet.
+func (c *DropletCollector) imageCounts(ctx context.Context, id int) (int, int, error) {
+	var backups, snapshots int
+	err := paginate(ctx, c.perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := c.client.Droplets.Backups(ctx, id, opt)
+		backups += len(page)
+		return resp, err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	err = paginate(ctx, c.perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := c.client.Droplets.Snapshots(ctx, id, opt)
+		snapshots += len(page)
+		return resp, err
+	})
+	return backups, snapshots, err
+}
+
+func listDroplets(ctx context.Context, client *godo.Client, perPage int) ([]godo.Droplet, error) {
+	var droplets []godo.Droplet
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.Droplets.List(ctx, opt)
+		droplets = append(droplets, page...)
+		return resp, err
+	})
+	return droplets, err
+}
@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+var dropletFixtures = map[string]string{
+	"/v2/droplets": `{"droplets":[
+		{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","vcpus":2,"memory":2048,"disk":50,"locked":false,
+		 "features":["backups","ipv6"],"created_at":"2020-01-02T03:04:05Z","size_slug":"s-2vcpu-2gb","size":{"price_hourly":0.02976,"price_monthly":20},
+		 "image":{"distribution":"Ubuntu","name":"20.04 (LTS) x64","type":"base"},"tags":["env:prod","team:a"]},
+		{"id":2,"name":"db","region":{"slug":"fra1"},"status":"off","vcpus":4,"memory":8192,"disk":160,"locked":true,
+		 "features":["monitoring"],"created_at":"2021-01-02T03:04:05Z","size_slug":"gpu-h100x1-80gb","size":{"price_hourly":0.5,"price_monthly":300},
+		 "image":{"distribution":"Debian","name":"db-snapshot","type":"snapshot"},"tags":["env:dev","plain"]}
+	],"links":{}}`,
+	// The migration is older than any lookback of the account's actions, but still in progress.
+	"/v2/droplets/2/actions": `{"actions":[
+		{"id":10,"status":"in-progress","type":"migrate","resource_type":"droplet","resource_id":2,"started_at":"2020-01-01T00:00:00Z"},
+		{"id":9,"status":"completed","type":"migrate","resource_type":"droplet","resource_id":2,"started_at":"2019-01-01T00:00:00Z"}
+	],"links":{"pages":{"next":"http://example.com/v2/droplets/2/actions?page=2"}}}`,
+}
+
+func TestDropletCollector(t *testing.T) {
+	api := newTestAPI(t, dropletFixtures)
+	c := testConfig(api.client(t))
+	c.DropletTagKeys = []string{"env"}
+	mfs := gather(t, newTestCollector(t, "droplet", c))
+
+	web := []string{"id=1", "name=web", "region=nyc1"}
+	db := []string{"id=2", "name=db", "region=fra1"}
+
+	assertMetric(t, mfs, 1, "digitalocean_droplet_up", web...)
+	assertMetric(t, mfs, 0, "digitalocean_droplet_up", db...)
+	assertMetric(t, mfs, 2, "digitalocean_droplet_cpus", web...)
+	assertMetric(t, mfs, 2048*1024*1024, "digitalocean_droplet_memory_bytes", web...)
+	assertMetric(t, mfs, 50*1000*1000*1000, "digitalocean_droplet_disk_bytes", web...)
+	assertMetric(t, mfs, 20, "digitalocean_droplet_price_monthly", web...)
+	assertMetric(t, mfs, 0, "digitalocean_droplet_locked", web...)
+	assertMetric(t, mfs, 1, "digitalocean_droplet_locked", db...)
+	assertMetric(t, mfs, 0, "digitalocean_droplet_migrating", web...)
+	assertMetric(t, mfs, 1, "digitalocean_droplet_migrating", db...)
+	assertMetric(t, mfs, 1, "digitalocean_droplet_image", append(web, "distribution=Ubuntu", "image_name=20.04 (LTS) x64", "source_type=distribution")...)
+	assertMetric(t, mfs, 1, "digitalocean_droplet_image", append(db, "distribution=Debian", "image_name=db-snapshot", "source_type=snapshot")...)
+
+	assertMetric(t, mfs, 1, "digitalocean_droplets_with_backups")
+	assertMetric(t, mfs, 1, "digitalocean_droplets_with_monitoring")
+	assertMetric(t, mfs, 1, "digitalocean_droplets_with_ipv6")
+	assertMetric(t, mfs, 1, "digitalocean_droplets_gpu_count")
+	assertMetric(t, mfs, 1, "digitalocean_droplet_info", append(db, "size=gpu-h100x1-80gb", "status=off", "distribution=Debian", "image_name=db-snapshot", "source_type=snapshot", "is_gpu=true")...)
+	assertMetric(t, mfs, 1, "digitalocean_droplets_by_tag", "tag_key=env", "tag_value=prod")
+	assertMetric(t, mfs, 1, "digitalocean_droplets_by_tag", "tag_key=env", "tag_value=dev")
+	assertMetric(t, mfs, 1609556645, "digitalocean_droplet_newest_created_timestamp_seconds")
+
+	if n := api.requested("/v2/droplets"); n != 1 {
+		t.Errorf("droplets were listed %d times, want 1", n)
+	}
+	// Only locked droplets can have an action in progress, and paging stops at the completed actions.
+	if n := api.requested("/v2/droplets/1/actions"); n != 0 {
+		t.Errorf("actions of the unlocked droplet were listed %d times, want 0", n)
+	}
+	if n := api.requested("/v2/droplets/2/actions"); n != 1 {
+		t.Errorf("actions of the locked droplet were listed %d times, want 1", n)
+	}
+}
+
+func TestDropletCollectorInfoOnly(t *testing.T) {
+	api := newTestAPI(t, dropletFixtures)
+	c := testConfig(api.client(t))
+	c.DropletInfoOnly = true
+	mfs := gather(t, newTestCollector(t, "droplet", c))
+
+	web := []string{"id=1", "name=web", "region=nyc1"}
+	db := []string{"id=2", "name=db", "region=fra1"}
+
+	assertMetric(t, mfs, 1, "digitalocean_droplet_info", append(web, "size=s-2vcpu-2gb", "status=active", "distribution=Ubuntu", "image_name=20.04 (LTS) x64", "source_type=distribution", "is_gpu=false")...)
+	assertMetric(t, mfs, 1, "digitalocean_droplet_info", append(db, "size=gpu-h100x1-80gb", "status=off", "distribution=Debian", "image_name=db-snapshot", "source_type=snapshot", "is_gpu=true")...)
+	assertNoMetric(t, mfs, "digitalocean_droplet_up", web...)
+	// Info only mode doesn't need the actions.
+	if n := api.requested("/v2/droplets/2/actions"); n != 0 {
+		t.Errorf("actions were listed %d times, want 0", n)
+	}
+}
+
+func TestDropletCollectorListError(t *testing.T) {
+	api := newTestAPI(t, map[string]string{})
+	mfs := gather(t, newTestCollector(t, "droplet", testConfig(api.client(t))))
+
+	// Without the list the counts are unknown, not 0.
+	assertNoMetric(t, mfs, "digitalocean_droplets_with_backups")
+	assertNoMetric(t, mfs, "digitalocean_collector_last_success_timestamp_seconds", "collector=droplet")
+}
+
+func TestSplitKeyTag(t *testing.T) {
+	keys := []string{"env", "team"}
+	for _, tc := range []struct {
+		tag        string
+		key, value string
+		ok         bool
+	}{
+		{tag: "env:prod", key: "env", value: "prod", ok: true},
+		{tag: "team:a:b", key: "team", value: "a:b", ok: true},
+		{tag: "env:", key: "env", value: "", ok: true},
+		{tag: "plain"},
+		{tag: ""},
+		{tag: ":prod"},
+		{tag: "owner:bob"},
+		{tag: "Env:prod"},
+	} {
+		t.Run(tc.tag, func(t *testing.T) {
+			key, value, ok := splitKeyTag(tc.tag, keys)
+			if key != tc.key || value != tc.value || ok != tc.ok {
+				t.Errorf("splitKeyTag(%q) = %q, %q, %v, want %q, %q, %v", tc.tag, key, value, ok, tc.key, tc.value, tc.ok)
+			}
+		})
+	}
+}
+
+func TestTagLabels(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		tags []string
+		keys []string
+		want []string
+	}{
+		{name: "one value per key", tags: []string{"env:prod", "team:a"}, keys: []string{"env", "team"}, want: []string{"prod", "a"}},
+		{name: "keys keep their order", tags: []string{"env:prod", "team:a"}, keys: []string{"team", "env"}, want: []string{"a", "prod"}},
+		{name: "missing key", tags: []string{"env:prod"}, keys: []string{"env", "team"}, want: []string{"prod", ""}},
+		{name: "no tags", keys: []string{"env"}, want: []string{""}},
+		{name: "tags without a colon", tags: []string{"plain", "env"}, keys: []string{"env"}, want: []string{""}},
+		{name: "repeated key", tags: []string{"team:b", "team:a"}, keys: []string{"team"}, want: []string{"a,b"}},
+		{name: "empty value", tags: []string{"team:", "team:a"}, keys: []string{"team"}, want: []string{"a"}},
+		{name: "empty key", tags: []string{":a"}, keys: []string{"team"}, want: []string{""}},
+		{name: "key not configured", tags: []string{"owner:bob", "env:prod"}, keys: []string{"env"}, want: []string{"prod"}},
+		{name: "no keys", tags: []string{"env:prod"}, want: []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tagLabels(tc.tags, tc.keys)
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+				t.Errorf("tagLabels(%q, %q) = %q, want %q", tc.tags, tc.keys, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// rejectEmptyTimes is how many empty listings in a row are rejected,
+// before an empty listing is believed to be real.
+const rejectEmptyTimes = 3
+
+// emptyGuard keeps a collector from publishing an empty listing, if the
+// previous listing wasn't empty. The API has been seen returning empty
+// listings transiently, which would otherwise drop all of the collector's metrics.
+type emptyGuard struct {
+	enabled bool
+	desc    *prometheus.Desc
+
+	mu       sync.Mutex
+	last     interface{}
+	lastLen  int
+	rejected int
+}
+
+func newEmptyGuard(collector string, enabled bool) *emptyGuard {
+	return &emptyGuard{
+		enabled: enabled,
+		desc: prometheus.NewDesc(
+			"digitalocean_collector_degraded",
+			"If 1 the collector's last listing was empty and its previous listing is exposed instead, 0 otherwise",
+			nil, prometheus.Labels{"collector": collector},
+		),
+	}
+}
+
+// filter returns the listing of n items unless it's empty while the previous
+// listing wasn't. Then the previous listing is returned instead.
+func (g *emptyGuard) filter(list interface{}, n int) interface{} {
+	if !g.enabled {
+		return list
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if n == 0 && g.lastLen > 0 && g.rejected < rejectEmptyTimes {
+		g.rejected++
+		return g.last
+	}
+
+	g.last, g.lastLen, g.rejected = list, n, 0
+	return list
+}
+
+// collect sends if the last listing was rejected.
+func (g *emptyGuard) collect(ch chan<- prometheus.Metric) {
+	if !g.enabled {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var degraded float64
+	if g.rejected > 0 {
+		degraded = 1
+	}
+	ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, degraded)
+}
@@ -10,40 +10,46 @@ import (
 
 // ExporterCollector collects metrics, mostly runtime, about this exporter in general.
 type ExporterCollector struct {
-	logger    log.Logger
-	version   string
-	revision  string
-	buildDate string
-	goVersion string
-	startTime time.Time
+	logger      log.Logger
+	version     string
+	revision    string
+	buildDate   string
+	goVersion   string
+	godoVersion string
+	startTime   time.Time
 
 	StartTime *prometheus.Desc
 	BuildInfo *prometheus.Desc
+	GodoInfo  *prometheus.Desc
 }
 
-//logger, Version, Revision, BuildDate, GoVersion, StartTime
-
 // NewExporterCollector returns a new ExporterCollector.
-func NewExporterCollector(logger log.Logger, version string, revision string, buildDate string, goVersion string, startTime time.Time) *ExporterCollector {
+func NewExporterCollector(logger log.Logger, version string, revision string, buildDate string, goVersion string, godoVersion string, startTime time.Time) *ExporterCollector {
 	return &ExporterCollector{
 		logger: logger,
 
-		version:   version,
-		revision:  revision,
-		buildDate: buildDate,
-		goVersion: goVersion,
-		startTime: startTime,
+		version:     version,
+		revision:    revision,
+		buildDate:   buildDate,
+		goVersion:   goVersion,
+		godoVersion: godoVersion,
+		startTime:   startTime,
 
 		StartTime: prometheus.NewDesc(
-			"digitalocean_start_time",
+			"digitalocean_start_time_seconds",
 			"Unix timestamp of the start time",
 			nil, nil,
 		),
 		BuildInfo: prometheus.NewDesc(
 			"digitalocean_build_info",
-			"A metric with a constant '1' value labeled by version, revision, and branch from which the node_exporter was built.",
+			"A metric with a constant '1' value labeled by version, revision, and branch from which the digitalocean_exporter was built.",
 			[]string{"verison", "revision", "builddate", "goversion"}, nil,
 		),
+		GodoInfo: prometheus.NewDesc(
+			"digitalocean_exporter_godo_info",
+			"A metric with a constant '1' value labeled by the version of the godo client library the exporter was built with.",
+			[]string{"version"}, nil,
+		),
 	}
 }
 
@@ -51,6 +57,7 @@ func NewExporterCollector(logger log.Logger, version string, revision string, bu
 // collected by this Collector.
 func (c *ExporterCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.StartTime
+	ch <- c.GodoInfo
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -61,6 +68,7 @@ func (c *ExporterCollector) Collect(ch chan<- prometheus.Metric) {
 		"revision", c.revision,
 		"buildDate", c.buildDate,
 		"goVersion", c.goVersion,
+		"godoVersion", c.godoVersion,
 		"startTime", c.startTime,
 	)
 
@@ -75,4 +83,10 @@ func (c *ExporterCollector) Collect(ch chan<- prometheus.Metric) {
 		1.0,
 		c.version, c.revision, c.buildDate, c.goVersion,
 	)
+	ch <- prometheus.MustNewConstMetric(
+		c.GodoInfo,
+		prometheus.GaugeValue,
+		1.0,
+		c.godoVersion,
+	)
 }
@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"regexp"
+
+	"github.com/digitalocean/godo"
+)
+
+// DropletFilter excludes droplets from the droplet collector's metrics.
+type DropletFilter struct {
+	// NameExclude excludes droplets whose name matches, if set.
+	NameExclude *regexp.Regexp
+	// TagExclude excludes droplets with at least one matching tag, if set.
+	TagExclude *regexp.Regexp
+}
+
+// excludes reports whether the droplet should be left out.
+func (f DropletFilter) excludes(droplet godo.Droplet) bool {
+	if f.NameExclude != nil && f.NameExclude.MatchString(droplet.Name) {
+		return true
+	}
+	if f.TagExclude != nil {
+		for _, tag := range droplet.Tags {
+			if f.TagExclude.MatchString(tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
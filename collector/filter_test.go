@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestDropletFilterExcludes(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		filter  DropletFilter
+		droplet godo.Droplet
+		want    bool
+	}{
+		{name: "no filter", droplet: godo.Droplet{Name: "ci-1", Tags: []string{"env:dev"}}},
+		{name: "name matches", filter: DropletFilter{NameExclude: regexp.MustCompile("^ci-")}, droplet: godo.Droplet{Name: "ci-1"}, want: true},
+		{name: "name doesn't match", filter: DropletFilter{NameExclude: regexp.MustCompile("^ci-")}, droplet: godo.Droplet{Name: "web"}},
+		{name: "one tag matches", filter: DropletFilter{TagExclude: regexp.MustCompile("^env:dev$")}, droplet: godo.Droplet{Name: "web", Tags: []string{"team:a", "env:dev"}}, want: true},
+		{name: "no tag matches", filter: DropletFilter{TagExclude: regexp.MustCompile("^env:dev$")}, droplet: godo.Droplet{Name: "web", Tags: []string{"env:prod"}}},
+		{name: "no tags", filter: DropletFilter{TagExclude: regexp.MustCompile(".")}, droplet: godo.Droplet{Name: "web"}},
+		{
+			name:    "tag matches but name doesn't",
+			filter:  DropletFilter{NameExclude: regexp.MustCompile("^ci-"), TagExclude: regexp.MustCompile("^env:dev$")},
+			droplet: godo.Droplet{Name: "web", Tags: []string{"env:dev"}},
+			want:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.excludes(tc.droplet); got != tc.want {
+				t.Errorf("excludes = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+var filterFixtures = map[string]string{
+	"/v2/droplets": `{"droplets":[
+		{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{},"tags":["env:prod"]},
+		{"id":2,"name":"ci-1","region":{"slug":"nyc1"},"status":"active","size":{},"tags":["env:dev"]},
+		{"id":3,"name":"db","region":{"slug":"fra1"},"status":"active","size":{},"tags":["env:dev"]}
+	],"links":{}}`,
+	"/v2/droplets/1": `{"droplet":{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{},"tags":["env:prod"]}}`,
+	"/v2/droplets/2": `{"droplet":{"id":2,"name":"ci-1","region":{"slug":"nyc1"},"status":"active","size":{},"tags":["env:dev"]}}`,
+	"/v2/actions":    `{"actions":[],"links":{}}`,
+}
+
+func TestDropletCollectorFilter(t *testing.T) {
+	web := []string{"id=1", "name=web", "region=nyc1"}
+	ci := []string{"id=2", "name=ci-1", "region=nyc1"}
+	db := []string{"id=3", "name=db", "region=fra1"}
+
+	for _, tc := range []struct {
+		name    string
+		regions []string
+		ids     []int
+		filter  DropletFilter
+		want    [][]string
+		notWant [][]string
+	}{
+		{
+			name:    "name exclude only",
+			filter:  DropletFilter{NameExclude: regexp.MustCompile("^ci-")},
+			want:    [][]string{web, db},
+			notWant: [][]string{ci},
+		},
+		{
+			name:    "tag exclude only",
+			filter:  DropletFilter{TagExclude: regexp.MustCompile("^env:dev$")},
+			want:    [][]string{web},
+			notWant: [][]string{ci, db},
+		},
+		{
+			// ci-1 is in an included region, but excluded by its name.
+			name:    "region include and name exclude",
+			regions: []string{"nyc1"},
+			filter:  DropletFilter{NameExclude: regexp.MustCompile("^ci-")},
+			want:    [][]string{web},
+			notWant: [][]string{ci, db},
+		},
+		{
+			// ci-1 is one of the droplet IDs, but excluded by its tag.
+			name:    "droplet IDs and tag exclude",
+			ids:     []int{1, 2},
+			filter:  DropletFilter{TagExclude: regexp.MustCompile("^env:dev$")},
+			want:    [][]string{web},
+			notWant: [][]string{ci, db},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			api := newTestAPI(t, filterFixtures)
+			c := testConfig(api.client(t))
+			c.Regions = tc.regions
+			c.DropletFilter = tc.filter
+			c.Cache = NewScrapeCache(c.Client, c.PerPage, tc.ids)
+			mfs := gather(t, newTestCollector(t, "droplet", c))
+
+			for _, labels := range tc.want {
+				assertMetric(t, mfs, 1, "digitalocean_droplet_up", labels...)
+			}
+			for _, labels := range tc.notWant {
+				assertNoMetric(t, mfs, "digitalocean_droplet_up", labels...)
+			}
+		})
+	}
+}
@@ -11,29 +11,64 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("floating_ip", func(c Config) prometheus.Collector {
+		return NewFloatingIPCollector(c)
+	})
+}
+
 // FloatingIPCollector collects metrics about all floating ips.
 type FloatingIPCollector struct {
 	logger  log.Logger
 	client  *godo.Client
 	timeout time.Duration
+	perPage int
+	regions []string
+	cache   *ScrapeCache
+	// regionEmpty is whether the empty regions are collected from the droplets of the cache.
+	regionEmpty bool
+	// lastAction lists the actions of every floating ip.
+	lastAction bool
+	*lastSuccess
+	pages *pageCounter
 
-	Active *prometheus.Desc
+	Active      *prometheus.Desc
+	LastAction  *prometheus.Desc
+	RegionEmpty *prometheus.Desc
 }
 
-// NewFloatingIPCollector returns a new FloatingIPCollector.
-func NewFloatingIPCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *FloatingIPCollector {
+// NewFloatingIPCollector returns a new FloatingIPCollector built from the Config.
+// The floating ips are got from the cache.
+func NewFloatingIPCollector(c Config) *FloatingIPCollector {
 	labels := []string{"droplet_id", "droplet_name", "region", "ipv4"}
 
 	return &FloatingIPCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		cache:       c.Cache,
+		regionEmpty: c.enabled("droplet"),
+		lastAction:  c.FloatingIPLastAction,
+		lastSuccess: newLastSuccess("floating_ip"),
+		pages:       newPageCounter("floating_ip"),
 
 		Active: prometheus.NewDesc(
 			"digitalocean_floating_ipv4_active",
-			"If 1 the floating ip used by a droplet, 0 otherwise",
+			"If 1 the floating ip is assigned to a droplet, 0 otherwise",
 			labels, nil,
 		),
+		LastAction: prometheus.NewDesc(
+			"digitalocean_floating_ip_last_action_timestamp_seconds",
+			"Unix timestamp of the last action on the floating ip within the last 24 hours",
+			[]string{"region", "ipv4", "type"}, nil,
+		),
+		RegionEmpty: prometheus.NewDesc(
+			"digitalocean_reserved_ip_region_empty",
+			"If 1 there are reserved ips in the region but no droplets, 0 otherwise",
+			[]string{"region"}, nil,
+		),
 	}
 }
 
@@ -41,22 +76,69 @@ func NewFloatingIPCollector(logger log.Logger, client *godo.Client, timeout time
 // collected by this Collector.
 func (c *FloatingIPCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.Active
+	ch <- c.LastAction
+	ch <- c.RegionEmpty
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *FloatingIPCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
-	floatingIPs, _, err := c.client.FloatingIPs.List(ctx, nil)
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	floatingIPs, err := c.cache.floatingIPs(ctx)
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't list floating ips",
 			"err", err,
 		)
+		c.lastSuccess.collect(ch, false)
 		return
 	}
 
+	// Floating ips can only be assigned to droplets in their region,
+	// so they're wasted in regions without any droplets.
+	var dropletRegions map[string]bool
+	succeeded := true
+	if c.regionEmpty {
+		dropletRegions, err = c.dropletRegions(ctx)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list droplets",
+				"err", err,
+			)
+			succeeded = false
+		}
+	}
+
+	ipRegions := map[string]bool{}
+	for _, ip := range floatingIPs {
+		if inRegions(c.regions, ip.Region) {
+			ipRegions[ip.Region.Slug] = true
+		}
+	}
+	if dropletRegions != nil {
+		for region := range ipRegions {
+			var empty float64
+			if !dropletRegions[region] {
+				empty = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.RegionEmpty,
+				prometheus.GaugeValue,
+				empty,
+				region,
+			)
+		}
+	}
+
 	for _, ip := range floatingIPs {
+		if !inRegions(c.regions, ip.Region) {
+			continue
+		}
+
 		var active float64
 		var dropletID, dropletName string
 		if ip.Droplet != nil {
@@ -78,5 +160,94 @@ func (c *FloatingIPCollector) Collect(ch chan<- prometheus.Metric) {
 			active,
 			labels...,
 		)
+
+		if !c.lastAction {
+			continue
+		}
+		action, err := lastFloatingIPAction(ctx, c.client, ip.IP, c.perPage)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list floating ip actions",
+				"ip", ip.IP,
+				"err", err,
+			)
+			succeeded = false
+			continue
+		}
+		if action == nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.LastAction,
+			prometheus.GaugeValue,
+			float64(action.StartedAt.Unix()),
+			ip.Region.Slug, ip.IP, action.Type,
+		)
+	}
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+// dropletRegions returns the slugs of all regions with droplets, or nil if
+// only some droplets are collected, as the others may be in any region.
+func (c *FloatingIPCollector) dropletRegions(ctx context.Context) (map[string]bool, error) {
+	scope, err := c.cache.droplets(ctx)
+	if err != nil || scope.ids != nil {
+		return nil, err
+	}
+
+	regions := map[string]bool{}
+	for _, droplet := range scope.droplets {
+		if droplet.Region != nil {
+			regions[droplet.Region.Slug] = true
+		}
 	}
+	return regions, nil
+}
+
+func listFloatingIPs(ctx context.Context, client *godo.Client, perPage int) ([]godo.FloatingIP, error) {
+	var floatingIPs []godo.FloatingIP
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.FloatingIPs.List(ctx, opt)
+		floatingIPs = append(floatingIPs, page...)
+		return resp, err
+	})
+	return floatingIPs, err
+}
+
+// floatingIPActionWindow limits how far back the actions of a floating ip are read.
+const floatingIPActionWindow = 24 * time.Hour
+
+// lastFloatingIPAction returns the most recent action of a floating ip started
+// within the floatingIPActionWindow, or nil if there was none.
+func lastFloatingIPAction(ctx context.Context, client *godo.Client, ip string, perPage int) (*godo.Action, error) {
+	since := time.Now().Add(-floatingIPActionWindow)
+
+	var last *godo.Action
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		actions, resp, err := client.FloatingIPActions.List(ctx, ip, opt)
+		if err != nil {
+			return resp, err
+		}
+
+		outside := false
+		for i, action := range actions {
+			if action.StartedAt == nil {
+				continue
+			}
+			if action.StartedAt.Before(since) {
+				outside = true
+				continue
+			}
+			if last == nil || action.StartedAt.After(last.StartedAt.Time) {
+				last = &actions[i]
+			}
+		}
+		if outside {
+			return resp, errStopPaging
+		}
+		return resp, nil
+	})
+	return last, err
 }
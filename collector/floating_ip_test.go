@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+var floatingIPFixtures = map[string]string{
+	"/v2/floating_ips": `{"floating_ips":[
+		{"ip":"203.0.113.1","region":{"slug":"nyc1"},"droplet":{"id":1,"name":"web"}},
+		{"ip":"203.0.113.2","region":{"slug":"fra1"}}
+	],"links":{}}`,
+	"/v2/droplets": `{"droplets":[
+		{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{}}
+	],"links":{}}`,
+	"/v2/droplets/1": `{"droplet":{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{}}}`,
+	"/v2/floating_ips/203.0.113.1/actions": `{"actions":[
+		{"id":1,"type":"assign_ip","status":"completed","started_at":"` + time.Now().Add(-time.Hour).UTC().Format(time.RFC3339) + `"}
+	],"links":{}}`,
+	"/v2/floating_ips/203.0.113.2/actions": `{"actions":[],"links":{}}`,
+}
+
+func TestFloatingIPCollector(t *testing.T) {
+	api := newTestAPI(t, floatingIPFixtures)
+	mfs := gather(t, newTestCollector(t, "floating_ip", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 1, "digitalocean_floating_ipv4_active", "droplet_id=1", "droplet_name=web", "region=nyc1", "ipv4=203.0.113.1")
+	assertMetric(t, mfs, 0, "digitalocean_floating_ipv4_active", "droplet_id=", "droplet_name=", "region=fra1", "ipv4=203.0.113.2")
+	assertMetric(t, mfs, 0, "digitalocean_reserved_ip_region_empty", "region=nyc1")
+	assertMetric(t, mfs, 1, "digitalocean_reserved_ip_region_empty", "region=fra1")
+
+	// The actions are only listed with FloatingIPLastAction, one API call per floating ip.
+	if n := api.requested("/v2/floating_ips/203.0.113.1/actions"); n != 0 {
+		t.Errorf("actions were listed %d times, want 0", n)
+	}
+}
+
+func TestFloatingIPCollectorLastAction(t *testing.T) {
+	api := newTestAPI(t, floatingIPFixtures)
+	c := testConfig(api.client(t))
+	c.FloatingIPLastAction = true
+	mfs := gather(t, newTestCollector(t, "floating_ip", c))
+
+	if _, ok := metricValue(mfs, "digitalocean_floating_ip_last_action_timestamp_seconds", "region=nyc1", "ipv4=203.0.113.1", "type=assign_ip"); !ok {
+		t.Error("the last action of 203.0.113.1 wasn't collected")
+	}
+	assertNoMetric(t, mfs, "digitalocean_floating_ip_last_action_timestamp_seconds", "region=fra1", "ipv4=203.0.113.2", "type=assign_ip")
+}
+
+func TestFloatingIPCollectorRegions(t *testing.T) {
+	api := newTestAPI(t, floatingIPFixtures)
+	c := testConfig(api.client(t))
+	c.Regions = []string{"fra1"}
+	mfs := gather(t, newTestCollector(t, "floating_ip", c))
+
+	assertNoMetric(t, mfs, "digitalocean_floating_ipv4_active", "droplet_id=1", "droplet_name=web", "region=nyc1", "ipv4=203.0.113.1")
+	assertNoMetric(t, mfs, "digitalocean_reserved_ip_region_empty", "region=nyc1")
+	assertMetric(t, mfs, 1, "digitalocean_reserved_ip_region_empty", "region=fra1")
+}
+
+func TestFloatingIPCollectorDropletIDs(t *testing.T) {
+	api := newTestAPI(t, floatingIPFixtures)
+	c := testConfig(api.client(t))
+	c.Cache = NewScrapeCache(c.Client, c.PerPage, []int{1})
+	mfs := gather(t, newTestCollector(t, "floating_ip", c))
+
+	// Droplets other than 1 may be in fra1.
+	assertNoMetric(t, mfs, "digitalocean_reserved_ip_region_empty", "region=fra1")
+	assertMetric(t, mfs, 0, "digitalocean_floating_ipv4_active", "droplet_id=", "droplet_name=", "region=fra1", "ipv4=203.0.113.2")
+}
@@ -0,0 +1,162 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("functions", func(c Config) prometheus.Collector {
+		if !c.Functions {
+			return nil
+		}
+		return NewFunctionsCollector(c)
+	})
+}
+
+// functionsNamespace is a namespace of DigitalOcean Functions, which the vendored godo doesn't know about.
+type functionsNamespace struct {
+	// Namespace is the ID of the namespace, like fn-0a1b2c3d.
+	Namespace string `json:"namespace"`
+	// Label is the name the namespace was given.
+	Label  string `json:"label"`
+	Region string `json:"region"`
+}
+
+// FunctionsCollector collects metrics about the Functions namespaces of the account.
+type FunctionsCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	regions []string
+	*lastSuccess
+	pages *pageCounter
+
+	Namespaces *prometheus.Desc
+	Triggers   *prometheus.Desc
+}
+
+// NewFunctionsCollector returns a new FunctionsCollector built from the Config.
+// Every namespace's triggers are listed, which is one more API call per namespace.
+func NewFunctionsCollector(c Config) *FunctionsCollector {
+	return &FunctionsCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		lastSuccess: newLastSuccess("functions"),
+		pages:       newPageCounter("functions"),
+
+		Namespaces: prometheus.NewDesc(
+			"digitalocean_functions_namespace_count",
+			"Number of Functions namespaces by region",
+			[]string{"region"}, nil,
+		),
+		Triggers: prometheus.NewDesc(
+			"digitalocean_functions_trigger_count",
+			"Number of triggers of the Functions namespace",
+			[]string{"namespace", "label", "region"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *FunctionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Namespaces
+	ch <- c.Triggers
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *FunctionsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	namespaces, err := listFunctionsNamespaces(ctx, c.client, c.perPage)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list functions namespaces",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	succeeded := true
+	byRegion := map[string]int{}
+	for _, namespace := range namespaces {
+		if !inRegionSlugs(c.regions, namespace.Region) {
+			continue
+		}
+		byRegion[namespace.Region]++
+
+		// The triggers share the collector's timeout with the namespaces.
+		triggers, err := countFunctionsTriggers(ctx, c.client, c.perPage, namespace.Namespace)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list triggers of functions namespace",
+				"namespace", namespace.Namespace,
+				"err", err,
+			)
+			succeeded = false
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Triggers,
+			prometheus.GaugeValue,
+			float64(triggers),
+			namespace.Namespace, namespace.Label, namespace.Region,
+		)
+	}
+
+	for region, count := range byRegion {
+		ch <- prometheus.MustNewConstMetric(
+			c.Namespaces,
+			prometheus.GaugeValue,
+			float64(count),
+			region,
+		)
+	}
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+func listFunctionsNamespaces(ctx context.Context, client *godo.Client, perPage int) ([]functionsNamespace, error) {
+	var namespaces []functionsNamespace
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Namespaces []functionsNamespace `json:"namespaces"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/functions/namespaces", opt, &root)
+		namespaces = append(namespaces, root.Namespaces...)
+		return resp, err
+	})
+	return namespaces, err
+}
+
+// countFunctionsTriggers returns the number of triggers of the Functions namespace with the id.
+func countFunctionsTriggers(ctx context.Context, client *godo.Client, perPage int, id string) (int, error) {
+	var n int
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Triggers []struct{} `json:"triggers"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/functions/namespaces/"+id+"/triggers", opt, &root)
+		n += len(root.Triggers)
+		return resp, err
+	})
+	return n, err
+}
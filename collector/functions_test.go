@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"testing"
+)
+
+var functionsFixtures = map[string]string{
+	"/v2/functions/namespaces": `{"namespaces":[
+		{"namespace":"fn-1","label":"api","region":"nyc1"},
+		{"namespace":"fn-2","label":"jobs","region":"nyc1"},
+		{"namespace":"fn-3","label":"hooks","region":"fra1"}
+	]}`,
+	"/v2/functions/namespaces/fn-1/triggers": `{"triggers":[
+		{"name":"nightly","function":"report","type":"SCHEDULED","is_enabled":true},
+		{"name":"hourly","function":"cleanup","type":"SCHEDULED","is_enabled":false}
+	]}`,
+	"/v2/functions/namespaces/fn-2/triggers": `{"triggers":[]}`,
+	"/v2/functions/namespaces/fn-3/triggers": `{"triggers":[{"name":"daily","function":"sync","type":"SCHEDULED","is_enabled":true}]}`,
+}
+
+func TestFunctionsCollector(t *testing.T) {
+	api := newTestAPI(t, functionsFixtures)
+	c := testConfig(api.client(t))
+	c.Functions = true
+	mfs := gather(t, newTestCollector(t, "functions", c))
+
+	assertMetric(t, mfs, 2, "digitalocean_functions_namespace_count", "region=nyc1")
+	assertMetric(t, mfs, 1, "digitalocean_functions_namespace_count", "region=fra1")
+	assertMetric(t, mfs, 2, "digitalocean_functions_trigger_count", "namespace=fn-1", "label=api", "region=nyc1")
+	// Namespaces without triggers have a count of 0.
+	assertMetric(t, mfs, 0, "digitalocean_functions_trigger_count", "namespace=fn-2", "label=jobs", "region=nyc1")
+	assertMetric(t, mfs, 1, "digitalocean_functions_trigger_count", "namespace=fn-3", "label=hooks", "region=fra1")
+}
+
+func TestFunctionsCollectorRegions(t *testing.T) {
+	api := newTestAPI(t, functionsFixtures)
+	c := testConfig(api.client(t))
+	c.Functions = true
+	c.Regions = []string{"fra1"}
+	mfs := gather(t, newTestCollector(t, "functions", c))
+
+	assertNoMetric(t, mfs, "digitalocean_functions_namespace_count", "region=nyc1")
+	assertMetric(t, mfs, 1, "digitalocean_functions_namespace_count", "region=fra1")
+	if n := api.requested("/v2/functions/namespaces/fn-1/triggers"); n != 0 {
+		t.Errorf("triggers of a namespace outside the regions were listed %d times, want 0", n)
+	}
+}
+
+func TestFunctionsCollectorDisabled(t *testing.T) {
+	api := newTestAPI(t, nil)
+	if _, ok := Collectors(testConfig(api.client(t)))["functions"]; ok {
+		t.Error("the functions collector was built without Functions")
+	}
+}
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// testTimeout is the timeout of collectors under test.
+const testTimeout = 5 * time.Second
+
+// testAPI is a fake DigitalOcean API serving fixtures keyed by the request's path.
+// Paths without a fixture are answered with a 404, like unknown resources.
+type testAPI struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	fixtures map[string]string
+	requests map[string]int
+}
+
+// newTestAPI starts a testAPI serving the fixtures, which is closed at the end of the test.
+func newTestAPI(t *testing.T, fixtures map[string]string) *testAPI {
+	api := &testAPI{fixtures: fixtures, requests: map[string]int{}}
+	api.Server = httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (api *testAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	api.requests[r.URL.Path]++
+	body, ok := api.fixtures[r.URL.Path]
+	api.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"id":"not_found","message":"The resource you were accessing could not be found."}`)
+		return
+	}
+	fmt.Fprint(w, body)
+}
+
+// client returns a godo client sending all requests to the testAPI.
+func (api *testAPI) client(t *testing.T) *godo.Client {
+	client := godo.NewClient(nil)
+	u, err := url.Parse(api.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = u
+	return client
+}
+
+// requested returns how often path was requested.
+func (api *testAPI) requested(path string) int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.requests[path]
+}
+
+// gather registers the collector with a new pedantic registry and gathers it,
+// failing the test if the gathered metrics are inconsistent.
+func gather(t *testing.T, c prometheus.Collector) []*dto.MetricFamily {
+	r := prometheus.NewPedanticRegistry()
+	if err := r.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mfs
+}
+
+// metricValue returns the value of the gathered metric with the name and labels,
+// which must be all of the metric's labels in the form name=value.
+func metricValue(mfs []*dto.MetricFamily, name string, labels ...string) (float64, bool) {
+	sorted := append([]string(nil), labels...)
+	sort.Strings(sorted)
+	want := strings.Join(sorted, ",")
+
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			var pairs []string
+			for _, lp := range m.GetLabel() {
+				pairs = append(pairs, lp.GetName()+"="+lp.GetValue())
+			}
+			sort.Strings(pairs)
+			if strings.Join(pairs, ",") != want {
+				continue
+			}
+			switch {
+			case m.Gauge != nil:
+				return m.Gauge.GetValue(), true
+			case m.Counter != nil:
+				return m.Counter.GetValue(), true
+			case m.Untyped != nil:
+				return m.Untyped.GetValue(), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// assertMetric fails the test unless the gathered metric with the name and labels has the value.
+func assertMetric(t *testing.T, mfs []*dto.MetricFamily, want float64, name string, labels ...string) {
+	t.Helper()
+	got, ok := metricValue(mfs, name, labels...)
+	if !ok {
+		t.Errorf("metric %s{%s} wasn't collected", name, strings.Join(labels, ","))
+		return
+	}
+	if got != want {
+		t.Errorf("metric %s{%s} = %v, want %v", name, strings.Join(labels, ","), got, want)
+	}
+}
+
+// assertNoMetric fails the test if a metric with the name and labels was gathered.
+func assertNoMetric(t *testing.T, mfs []*dto.MetricFamily, name string, labels ...string) {
+	t.Helper()
+	if got, ok := metricValue(mfs, name, labels...); ok {
+		t.Errorf("metric %s{%s} = %v was collected, want none", name, strings.Join(labels, ","), got)
+	}
+}
+
+// newTestCollector builds the collector registered under name from the Config.
+func newTestCollector(t *testing.T, name string, c Config) prometheus.Collector {
+	factory, ok := factories[name]
+	if !ok {
+		t.Fatalf("collector %q isn't registered", name)
+	}
+	return factory(c)
+}
+
+// testConfig returns the Config of collectors using the client with a discarding logger.
+// Its cache is new, so every test lists the resources again.
+func testConfig(client *godo.Client) Config {
+	return Config{
+		Logger:  log.NewNopLogger(),
+		Client:  client,
+		Timeout: testTimeout,
+		PerPage: MaxPerPage,
+		Cache:   NewScrapeCache(client, MaxPerPage, nil),
+	}
+}
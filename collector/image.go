@@ -11,22 +11,34 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("image", func(c Config) prometheus.Collector {
+		return NewImageCollector(c)
+	})
+}
+
 // ImageCollector collects metrics about all images created by the user.
 type ImageCollector struct {
 	logger  log.Logger
 	client  *godo.Client
 	timeout time.Duration
+	perPage int
+	*lastSuccess
+	pages *pageCounter
 
 	MinDiskSize *prometheus.Desc
 }
 
-// NewImageCollector returns a new ImageCollector.
-func NewImageCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *ImageCollector {
+// NewImageCollector returns a new ImageCollector built from the Config.
+func NewImageCollector(c Config) *ImageCollector {
 	labels := []string{"id", "name", "region", "type", "distribution"}
 	return &ImageCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		lastSuccess: newLastSuccess("image"),
+		pages:       newPageCounter("image"),
 
 		MinDiskSize: prometheus.NewDesc(
 			"digitalocean_image_min_disk_size_bytes",
@@ -40,13 +52,18 @@ func NewImageCollector(logger log.Logger, client *godo.Client, timeout time.Dura
 // collected by this Collector.
 func (c *ImageCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.MinDiskSize
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *ImageCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
-	images, _, err := c.client.Images.ListUser(ctx, nil)
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	images, err := listImages(ctx, c.client, c.perPage)
+	c.lastSuccess.collect(ch, err == nil)
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't list volumes",
@@ -64,3 +81,13 @@ func (c *ImageCollector) Collect(ch chan<- prometheus.Metric) {
 		)
 	}
 }
+
+func listImages(ctx context.Context, client *godo.Client, perPage int) ([]godo.Image, error) {
+	var images []godo.Image
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.Images.ListUser(ctx, opt)
+		images = append(images, page...)
+		return resp, err
+	})
+	return images, err
+}
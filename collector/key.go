@@ -11,21 +11,33 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("key", func(c Config) prometheus.Collector {
+		return NewKeyCollector(c)
+	})
+}
+
 // KeyCollector collects metrics about ssh keys added to the account.
 type KeyCollector struct {
 	logger  log.Logger
 	client  *godo.Client
 	timeout time.Duration
+	perPage int
+	*lastSuccess
+	pages *pageCounter
 
 	Key *prometheus.Desc
 }
 
-// NewKeyCollector returns a new KeyCollector.
-func NewKeyCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *KeyCollector {
+// NewKeyCollector returns a new KeyCollector built from the Config.
+func NewKeyCollector(c Config) *KeyCollector {
 	return &KeyCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		lastSuccess: newLastSuccess("key"),
+		pages:       newPageCounter("key"),
 
 		Key: prometheus.NewDesc(
 			"digitalocean_key",
@@ -40,13 +52,18 @@ func NewKeyCollector(logger log.Logger, client *godo.Client, timeout time.Durati
 // collected by this Collector.
 func (c *KeyCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.Key
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *KeyCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
-	keys, _, err := c.client.Keys.List(ctx, nil)
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	keys, err := listKeys(ctx, c.client, c.perPage)
+	c.lastSuccess.collect(ch, err == nil)
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't list keys",
@@ -63,3 +80,13 @@ func (c *KeyCollector) Collect(ch chan<- prometheus.Metric) {
 		)
 	}
 }
+
+func listKeys(ctx context.Context, client *godo.Client, perPage int) ([]godo.Key, error) {
+	var keys []godo.Key
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.Keys.List(ctx, opt)
+		keys = append(keys, page...)
+		return resp, err
+	})
+	return keys, err
+}
@@ -0,0 +1,255 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("kubernetes", func(c Config) prometheus.Collector {
+		return NewKubernetesCollector(c)
+	})
+}
+
+// kubernetesCluster is a Kubernetes cluster, which the vendored godo doesn't know about.
+type kubernetesCluster struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Region  string `json:"region"`
+	Version string `json:"version_slug"`
+	Status  struct {
+		// State is one of running, provisioning, degraded, error, deleted, upgrading or deleting.
+		State string `json:"state"`
+	} `json:"status"`
+	NodePools []kubernetesNodePool `json:"node_pools"`
+}
+
+type kubernetesNodePool struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	Size      string           `json:"size"`
+	Count     int              `json:"count"`
+	AutoScale bool             `json:"auto_scale"`
+	MinNodes  int              `json:"min_nodes"`
+	MaxNodes  int              `json:"max_nodes"`
+	Nodes     []kubernetesNode `json:"nodes"`
+}
+
+type kubernetesNode struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status struct {
+		// State is one of provisioning, running, draining or deleting.
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+// KubernetesCollector collects metrics about the Kubernetes clusters of the account.
+type KubernetesCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	regions []string
+	*lastSuccess
+	pages *pageCounter
+
+	ClusterInfo       *prometheus.Desc
+	ClusterUp         *prometheus.Desc
+	NodePoolNodes     *prometheus.Desc
+	NodePoolRunning   *prometheus.Desc
+	NodePoolMinNodes  *prometheus.Desc
+	NodePoolMaxNodes  *prometheus.Desc
+	NodePoolAutoScale *prometheus.Desc
+	NodeUp            *prometheus.Desc
+}
+
+// NewKubernetesCollector returns a new KubernetesCollector built from the Config.
+func NewKubernetesCollector(c Config) *KubernetesCollector {
+	labels := []string{"id", "name", "region"}
+	poolLabels := []string{"cluster_id", "cluster_name", "id", "name"}
+
+	return &KubernetesCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		lastSuccess: newLastSuccess("kubernetes"),
+		pages:       newPageCounter("kubernetes"),
+
+		ClusterInfo: prometheus.NewDesc(
+			"digitalocean_kubernetes_cluster_info",
+			"A metric with a constant '1' value labeled by the cluster's Kubernetes version and state",
+			append(labels, "version", "state"), nil,
+		),
+		ClusterUp: prometheus.NewDesc(
+			"digitalocean_kubernetes_cluster_up",
+			"If 1 the cluster is running, 0 otherwise, like while it's provisioning, upgrading or degraded",
+			labels, nil,
+		),
+		NodePoolNodes: prometheus.NewDesc(
+			"digitalocean_kubernetes_node_pool_nodes",
+			"Number of nodes the node pool should have",
+			append(poolLabels, "size"), nil,
+		),
+		NodePoolRunning: prometheus.NewDesc(
+			"digitalocean_kubernetes_node_pool_running_nodes",
+			"Number of the node pool's nodes that are running",
+			poolLabels, nil,
+		),
+		NodePoolAutoScale: prometheus.NewDesc(
+			"digitalocean_kubernetes_node_pool_auto_scale",
+			"If 1 the node pool is scaled automatically between its minimum and maximum number of nodes, 0 otherwise",
+			poolLabels, nil,
+		),
+		NodePoolMinNodes: prometheus.NewDesc(
+			"digitalocean_kubernetes_node_pool_min_nodes",
+			"Minimum number of nodes of the automatically scaled node pool",
+			poolLabels, nil,
+		),
+		NodePoolMaxNodes: prometheus.NewDesc(
+			"digitalocean_kubernetes_node_pool_max_nodes",
+			"Maximum number of nodes of the automatically scaled node pool",
+			poolLabels, nil,
+		),
+		NodeUp: prometheus.NewDesc(
+			"digitalocean_kubernetes_node_up",
+			"If 1 the node is running, 0 otherwise, like while it's provisioning or draining",
+			[]string{"cluster_id", "cluster_name", "node_pool", "id", "name"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *KubernetesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ClusterInfo
+	ch <- c.ClusterUp
+	ch <- c.NodePoolNodes
+	ch <- c.NodePoolRunning
+	ch <- c.NodePoolAutoScale
+	ch <- c.NodePoolMinNodes
+	ch <- c.NodePoolMaxNodes
+	ch <- c.NodeUp
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *KubernetesCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	clusters, err := listKubernetesClusters(ctx, c.client, c.perPage)
+	c.lastSuccess.collect(ch, err == nil)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list kubernetes clusters",
+			"err", err,
+		)
+		return
+	}
+
+	for _, cluster := range clusters {
+		if !inRegionSlugs(c.regions, cluster.Region) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.ClusterInfo,
+			prometheus.GaugeValue,
+			1.0,
+			cluster.ID, cluster.Name, cluster.Region, cluster.Version, cluster.Status.State,
+		)
+
+		var up float64
+		if cluster.Status.State == "running" {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.ClusterUp,
+			prometheus.GaugeValue,
+			up,
+			cluster.ID, cluster.Name, cluster.Region,
+		)
+
+		for _, pool := range cluster.NodePools {
+			labels := []string{cluster.ID, cluster.Name, pool.ID, pool.Name}
+
+			ch <- prometheus.MustNewConstMetric(
+				c.NodePoolNodes,
+				prometheus.GaugeValue,
+				float64(pool.Count),
+				append(labels, pool.Size)...,
+			)
+
+			var running int
+			for _, node := range pool.Nodes {
+				var up float64
+				if node.Status.State == "running" {
+					up = 1
+					running++
+				}
+				ch <- prometheus.MustNewConstMetric(
+					c.NodeUp,
+					prometheus.GaugeValue,
+					up,
+					cluster.ID, cluster.Name, pool.Name, node.ID, node.Name,
+				)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.NodePoolRunning,
+				prometheus.GaugeValue,
+				float64(running),
+				labels...,
+			)
+
+			var autoScale float64
+			if pool.AutoScale {
+				autoScale = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.NodePoolAutoScale,
+				prometheus.GaugeValue,
+				autoScale,
+				labels...,
+			)
+			// The limits of node pools with a fixed size are meaningless.
+			if pool.AutoScale {
+				ch <- prometheus.MustNewConstMetric(
+					c.NodePoolMinNodes,
+					prometheus.GaugeValue,
+					float64(pool.MinNodes),
+					labels...,
+				)
+				ch <- prometheus.MustNewConstMetric(
+					c.NodePoolMaxNodes,
+					prometheus.GaugeValue,
+					float64(pool.MaxNodes),
+					labels...,
+				)
+			}
+		}
+	}
+}
+
+func listKubernetesClusters(ctx context.Context, client *godo.Client, perPage int) ([]kubernetesCluster, error) {
+	var clusters []kubernetesCluster
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Clusters []kubernetesCluster `json:"kubernetes_clusters"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/kubernetes/clusters", opt, &root)
+		clusters = append(clusters, root.Clusters...)
+		return resp, err
+	})
+	return clusters, err
+}
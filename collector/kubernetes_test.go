@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"testing"
+)
+
+var kubernetesFixtures = map[string]string{
+	"/v2/kubernetes/clusters": `{"kubernetes_clusters":[
+		{"id":"k1","name":"prod","region":"nyc1","version_slug":"1.29.1-do.0","status":{"state":"running"},"node_pools":[
+			{"id":"p1","name":"web","size":"s-2vcpu-4gb","count":2,"auto_scale":true,"min_nodes":1,"max_nodes":5,"nodes":[
+				{"id":"n1","name":"web-a","status":{"state":"running"}},
+				{"id":"n2","name":"web-b","status":{"state":"provisioning"}}
+			]}
+		]},
+		{"id":"k2","name":"dev","region":"fra1","version_slug":"1.28.5-do.0","status":{"state":"provisioning"},"node_pools":[
+			{"id":"p2","name":"default","size":"s-1vcpu-2gb","count":1,"auto_scale":false,"nodes":[]}
+		]}
+	],"links":{}}`,
+}
+
+func TestKubernetesCollector(t *testing.T) {
+	api := newTestAPI(t, kubernetesFixtures)
+	mfs := gather(t, newTestCollector(t, "kubernetes", testConfig(api.client(t))))
+
+	prod := []string{"id=k1", "name=prod", "region=nyc1"}
+	dev := []string{"id=k2", "name=dev", "region=fra1"}
+	web := []string{"cluster_id=k1", "cluster_name=prod", "id=p1", "name=web"}
+	pool := []string{"cluster_id=k2", "cluster_name=dev", "id=p2", "name=default"}
+
+	assertMetric(t, mfs, 1, "digitalocean_kubernetes_cluster_info", append(prod, "version=1.29.1-do.0", "state=running")...)
+	assertMetric(t, mfs, 1, "digitalocean_kubernetes_cluster_info", append(dev, "version=1.28.5-do.0", "state=provisioning")...)
+	assertMetric(t, mfs, 1, "digitalocean_kubernetes_cluster_up", prod...)
+	assertMetric(t, mfs, 0, "digitalocean_kubernetes_cluster_up", dev...)
+	assertMetric(t, mfs, 2, "digitalocean_kubernetes_node_pool_nodes", append(web, "size=s-2vcpu-4gb")...)
+	assertMetric(t, mfs, 1, "digitalocean_kubernetes_node_pool_running_nodes", web...)
+	assertMetric(t, mfs, 0, "digitalocean_kubernetes_node_pool_running_nodes", pool...)
+	assertMetric(t, mfs, 1, "digitalocean_kubernetes_node_pool_auto_scale", web...)
+	assertMetric(t, mfs, 1, "digitalocean_kubernetes_node_pool_min_nodes", web...)
+	assertMetric(t, mfs, 5, "digitalocean_kubernetes_node_pool_max_nodes", web...)
+	assertMetric(t, mfs, 0, "digitalocean_kubernetes_node_pool_auto_scale", pool...)
+	assertNoMetric(t, mfs, "digitalocean_kubernetes_node_pool_max_nodes", pool...)
+	assertMetric(t, mfs, 1, "digitalocean_kubernetes_node_up", "cluster_id=k1", "cluster_name=prod", "node_pool=web", "id=n1", "name=web-a")
+	assertMetric(t, mfs, 0, "digitalocean_kubernetes_node_up", "cluster_id=k1", "cluster_name=prod", "node_pool=web", "id=n2", "name=web-b")
+}
+
+func TestKubernetesCollectorRegions(t *testing.T) {
+	api := newTestAPI(t, kubernetesFixtures)
+	c := testConfig(api.client(t))
+	c.Regions = []string{"fra1"}
+	mfs := gather(t, newTestCollector(t, "kubernetes", c))
+
+	assertNoMetric(t, mfs, "digitalocean_kubernetes_cluster_up", "id=k1", "name=prod", "region=nyc1")
+	assertMetric(t, mfs, 0, "digitalocean_kubernetes_cluster_up", "id=k2", "name=dev", "region=fra1")
+}
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/digitalocean/godo"
@@ -10,59 +11,237 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("loadbalancer", func(c Config) prometheus.Collector {
+		return NewLoadBalancerCollector(c)
+	})
+}
+
+// apiLoadBalancer is a load balancer with the fields of the API the vendored godo doesn't decode.
+type apiLoadBalancer struct {
+	godo.LoadBalancer
+	VPCUUID string `json:"vpc_uuid"`
+	// Network is either EXTERNAL or INTERNAL.
+	Network string `json:"network"`
+
+	DisableLetsEncryptDNSRecords bool `json:"disable_lets_encrypt_dns_records"`
+	EnableProxyProtocol          bool `json:"enable_proxy_protocol"`
+	EnableBackendKeepalive       bool `json:"enable_backend_keepalive"`
+}
+
 // LoadBalancerCollector collects metrics about LoadBalancers of that account.
 type LoadBalancerCollector struct {
 	logger  log.Logger
 	client  *godo.Client
 	timeout time.Duration
+	perPage int
+	regions []string
+	cache   *ScrapeCache
+	// healthy is whether the healthy droplets are collected from the droplets of the cache.
+	healthy bool
+	*lastSuccess
+	pages *pageCounter
+	empty *emptyGuard
+
+	Info            *prometheus.Desc
+	Droplets        *prometheus.Desc
+	HealthyDroplets *prometheus.Desc
+	Status          *prometheus.Desc
+	ForwardingRule  *prometheus.Desc
+
+	RedirectHTTPToHTTPS          *prometheus.Desc
+	DisableLetsEncryptDNSRecords *prometheus.Desc
+	EnableProxyProtocol          *prometheus.Desc
+	EnableBackendKeepalive       *prometheus.Desc
+
+	Created24h *prometheus.Desc
+	Created    *prometheus.Desc
 
-	Droplets *prometheus.Desc
-	Status   *prometheus.Desc
+	HealthCheckInterval           *prometheus.Desc
+	HealthCheckTimeout            *prometheus.Desc
+	HealthCheckHealthyThreshold   *prometheus.Desc
+	HealthCheckUnhealthyThreshold *prometheus.Desc
 }
 
-// NewLoadBalancerCollector returns a new LoadBalancerCollector.
-func NewLoadBalancerCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *LoadBalancerCollector {
+// NewLoadBalancerCollector returns a new LoadBalancerCollector built from the Config.
+func NewLoadBalancerCollector(c Config) *LoadBalancerCollector {
+	labels := []string{"id", "name", "ip"}
+
 	return &LoadBalancerCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		cache:       c.Cache,
+		healthy:     c.enabled("droplet"),
+		lastSuccess: newLastSuccess("loadbalancer"),
+		pages:       newPageCounter("loadbalancer"),
+		empty:       newEmptyGuard("loadbalancer", c.RejectEmpty),
 
+		Info: prometheus.NewDesc(
+			"digitalocean_loadbalancer_info",
+			"A metric with a constant '1' value labeled by the load balancer's region, VPC and network",
+			append(labels, "region", "vpc_uuid", "network"), nil,
+		),
 		Droplets: prometheus.NewDesc(
 			"digitalocean_loadbalancer_droplets",
 			"The number of droplets this load balancer is proxying to",
 			[]string{"id", "name", "ip"},
 			nil,
 		),
+		HealthyDroplets: prometheus.NewDesc(
+			"digitalocean_loadbalancer_healthy_droplets",
+			"The number of active droplets this load balancer is proxying to. Derived from the droplets' status, not the load balancer's health checks",
+			labels, nil,
+		),
 		Status: prometheus.NewDesc(
 			"digitalocean_loadbalancer_status",
 			"The status of the load balancer, 1 if active",
 			[]string{"id", "name", "ip"},
 			nil,
 		),
+		ForwardingRule: prometheus.NewDesc(
+			"digitalocean_loadbalancer_forwarding_rule",
+			"Information about the protocols and ports a forwarding rule of the load balancer forwards",
+			append(labels, "entry_protocol", "entry_port", "target_protocol", "target_port"), nil,
+		),
+		RedirectHTTPToHTTPS: prometheus.NewDesc(
+			"digitalocean_loadbalancer_redirect_http_to_https",
+			"If 1 the load balancer redirects HTTP requests to HTTPS, 0 otherwise",
+			labels, nil,
+		),
+		DisableLetsEncryptDNSRecords: prometheus.NewDesc(
+			"digitalocean_loadbalancer_disable_lets_encrypt_dns_records",
+			"If 1 the load balancer doesn't create DNS records for its Let's Encrypt certificates, 0 otherwise",
+			labels, nil,
+		),
+		EnableProxyProtocol: prometheus.NewDesc(
+			"digitalocean_loadbalancer_enable_proxy_protocol",
+			"If 1 the load balancer forwards requests to the droplets using the PROXY protocol, 0 otherwise",
+			labels, nil,
+		),
+		EnableBackendKeepalive: prometheus.NewDesc(
+			"digitalocean_loadbalancer_enable_backend_keepalive",
+			"If 1 the load balancer keeps the connections to the droplets alive, 0 otherwise",
+			labels, nil,
+		),
+		Created24h: newCreated24hDesc("loadbalancer"),
+		Created: prometheus.NewDesc(
+			"digitalocean_loadbalancer_created_timestamp_seconds",
+			"Unix timestamp of the load balancer's creation",
+			labels, nil,
+		),
+		HealthCheckInterval: prometheus.NewDesc(
+			"digitalocean_loadbalancer_health_check_interval_seconds",
+			"Seconds between two health checks of a droplet",
+			labels, nil,
+		),
+		HealthCheckTimeout: prometheus.NewDesc(
+			"digitalocean_loadbalancer_health_check_timeout_seconds",
+			"Seconds to wait for a response to a health check before it fails",
+			labels, nil,
+		),
+		HealthCheckHealthyThreshold: prometheus.NewDesc(
+			"digitalocean_loadbalancer_health_check_healthy_threshold",
+			"Number of passed health checks before a droplet is considered healthy",
+			labels, nil,
+		),
+		HealthCheckUnhealthyThreshold: prometheus.NewDesc(
+			"digitalocean_loadbalancer_health_check_unhealthy_threshold",
+			"Number of failed health checks before a droplet is considered unhealthy",
+			labels, nil,
+		),
 	}
 }
 
 // Describe sends the super-set of all possible descriptors of metrics
 // collected by this Collector.
 func (c *LoadBalancerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Info
 	ch <- c.Droplets
+	ch <- c.HealthyDroplets
 	ch <- c.Status
+	ch <- c.ForwardingRule
+	ch <- c.RedirectHTTPToHTTPS
+	ch <- c.DisableLetsEncryptDNSRecords
+	ch <- c.EnableProxyProtocol
+	ch <- c.EnableBackendKeepalive
+	ch <- c.Created24h
+	ch <- c.Created
+	ch <- c.HealthCheckInterval
+	ch <- c.HealthCheckTimeout
+	ch <- c.HealthCheckHealthyThreshold
+	ch <- c.HealthCheckUnhealthyThreshold
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+	ch <- c.empty.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *LoadBalancerCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
 
-	lbs, _, err := c.client.LoadBalancers.List(ctx, nil)
+	lbs, err := listLoadBalancers(ctx, c.client, c.perPage)
 	if err != nil {
 		level.Warn(c.logger).Log(
-			"msg", "can't list keys",
+			"msg", "can't list load balancers",
 			"err", err,
 		)
+	} else {
+		lbs = c.empty.filter(lbs, len(lbs)).([]apiLoadBalancer)
+	}
+	c.empty.collect(ch)
+
+	// The API doesn't expose the health of a load balancer's droplets,
+	// so they're considered healthy if they're active.
+	var (
+		active      *activeDropletIDs
+		dropletsErr error
+	)
+	if c.healthy {
+		active, dropletsErr = c.activeDroplets(ctx)
+		if dropletsErr != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list droplets",
+				"err", dropletsErr,
+			)
+		}
 	}
+	c.lastSuccess.collect(ch, err == nil && dropletsErr == nil)
 
+	var created int
 	for _, lb := range lbs {
+		if !inRegions(c.regions, lb.Region) {
+			continue
+		}
+		if createdWithin24hRFC3339(lb.Created) {
+			created++
+		}
+		// Load balancers with a creation time that can't be parsed have no age.
+		if t, err := time.Parse(time.RFC3339, lb.Created); err == nil {
+			ch <- prometheus.MustNewConstMetric(
+				c.Created,
+				prometheus.GaugeValue,
+				float64(t.Unix()),
+				lb.ID, lb.Name, lb.IP,
+			)
+		}
+
+		var region string
+		if lb.Region != nil {
+			region = lb.Region.Slug
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Info,
+			prometheus.GaugeValue,
+			1.0,
+			lb.ID, lb.Name, lb.IP, region, lb.VPCUUID, lb.Network,
+		)
+
 		status := 0.0
 		if lb.Status == "active" {
 			status = 1
@@ -81,5 +260,133 @@ func (c *LoadBalancerCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(len(lb.DropletIDs)),
 			lb.ID, lb.Name, lb.IP,
 		)
+
+		if healthy, ok := active.count(lb.DropletIDs); ok {
+			ch <- prometheus.MustNewConstMetric(
+				c.HealthyDroplets,
+				prometheus.GaugeValue,
+				float64(healthy),
+				lb.ID, lb.Name, lb.IP,
+			)
+		}
+
+		for _, rule := range lb.ForwardingRules {
+			ch <- prometheus.MustNewConstMetric(
+				c.ForwardingRule,
+				prometheus.GaugeValue,
+				1.0,
+				lb.ID, lb.Name, lb.IP,
+				rule.EntryProtocol, strconv.Itoa(rule.EntryPort),
+				rule.TargetProtocol, strconv.Itoa(rule.TargetPort),
+			)
+		}
+
+		settings := []struct {
+			desc    *prometheus.Desc
+			enabled bool
+		}{
+			{c.RedirectHTTPToHTTPS, lb.RedirectHttpToHttps},
+			{c.DisableLetsEncryptDNSRecords, lb.DisableLetsEncryptDNSRecords},
+			{c.EnableProxyProtocol, lb.EnableProxyProtocol},
+			{c.EnableBackendKeepalive, lb.EnableBackendKeepalive},
+		}
+		for _, setting := range settings {
+			var enabled float64
+			if setting.enabled {
+				enabled = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				setting.desc,
+				prometheus.GaugeValue,
+				enabled,
+				lb.ID, lb.Name, lb.IP,
+			)
+		}
+
+		if lb.HealthCheck != nil {
+			ch <- prometheus.MustNewConstMetric(
+				c.HealthCheckInterval,
+				prometheus.GaugeValue,
+				float64(lb.HealthCheck.CheckIntervalSeconds),
+				lb.ID, lb.Name, lb.IP,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				c.HealthCheckTimeout,
+				prometheus.GaugeValue,
+				float64(lb.HealthCheck.ResponseTimeoutSeconds),
+				lb.ID, lb.Name, lb.IP,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				c.HealthCheckHealthyThreshold,
+				prometheus.GaugeValue,
+				float64(lb.HealthCheck.HealthyThreshold),
+				lb.ID, lb.Name, lb.IP,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				c.HealthCheckUnhealthyThreshold,
+				prometheus.GaugeValue,
+				float64(lb.HealthCheck.UnhealthyThreshold),
+				lb.ID, lb.Name, lb.IP,
+			)
+		}
+	}
+
+	// Without the list of load balancers the count would be 0, not unknown.
+	if err == nil {
+		ch <- prometheus.MustNewConstMetric(c.Created24h, prometheus.GaugeValue, float64(created))
+	}
+}
+
+// activeDropletIDs are the IDs of the active droplets the exporter collects.
+type activeDropletIDs struct {
+	scope *dropletScope
+	ids   map[int]bool
+}
+
+// count returns how many of the droplets are active. It's unknown if the active
+// droplets weren't listed or a droplet isn't one the exporter collects.
+func (a *activeDropletIDs) count(dropletIDs []int) (int, bool) {
+	if a == nil {
+		return 0, false
+	}
+	var active int
+	for _, id := range dropletIDs {
+		if !a.scope.known(id) {
+			return 0, false
+		}
+		if a.ids[id] {
+			active++
+		}
 	}
+	return active, true
+}
+
+// activeDroplets returns the IDs of the active droplets of the cache.
+func (c *LoadBalancerCollector) activeDroplets(ctx context.Context) (*activeDropletIDs, error) {
+	scope, err := c.cache.droplets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := &activeDropletIDs{scope: scope, ids: make(map[int]bool, len(scope.droplets))}
+	for _, droplet := range scope.droplets {
+		if droplet.Status == "active" {
+			active.ids[droplet.ID] = true
+		}
+	}
+	return active, nil
+}
+
+func listLoadBalancers(ctx context.Context, client *godo.Client, perPage int) ([]apiLoadBalancer, error) {
+	var lbs []apiLoadBalancer
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			LoadBalancers []apiLoadBalancer `json:"load_balancers"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/load_balancers", opt, &root)
+		lbs = append(lbs, root.LoadBalancers...)
+		return resp, err
+	})
+	return lbs, err
 }
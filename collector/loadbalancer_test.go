@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"testing"
+)
+
+var loadBalancerFixtures = map[string]string{
+	"/v2/load_balancers": `{"load_balancers":[
+		{"id":"lb1","name":"front","ip":"203.0.113.1","status":"active","region":{"slug":"nyc1"},"droplet_ids":[1,2,3],
+		 "vpc_uuid":"vpc-1","network":"EXTERNAL","enable_proxy_protocol":true,"enable_backend_keepalive":true,
+		 "created_at":"2020-01-02T03:04:05Z","redirect_http_to_https":true,
+		 "forwarding_rules":[{"entry_protocol":"https","entry_port":443,"target_protocol":"http","target_port":8080}],
+		 "health_check":{"protocol":"http","port":8080,"check_interval_seconds":10,"response_timeout_seconds":5,"healthy_threshold":3,"unhealthy_threshold":2}},
+		{"id":"lb2","name":"back","ip":"203.0.113.2","status":"new","region":{"slug":"fra1"},"droplet_ids":[],
+		 "vpc_uuid":"vpc-2","network":"INTERNAL","disable_lets_encrypt_dns_records":true,
+		 "created_at":"2021-01-02T03:04:05Z","forwarding_rules":[]}
+	],"links":{}}`,
+	"/v2/droplets": `{"droplets":[
+		{"id":1,"name":"a","region":{"slug":"nyc1"},"status":"active"},
+		{"id":2,"name":"b","region":{"slug":"nyc1"},"status":"off"},
+		{"id":3,"name":"c","region":{"slug":"nyc1"},"status":"active"}
+	],"links":{}}`,
+}
+
+func TestLoadBalancerCollector(t *testing.T) {
+	api := newTestAPI(t, loadBalancerFixtures)
+	mfs := gather(t, newTestCollector(t, "loadbalancer", testConfig(api.client(t))))
+
+	front := []string{"id=lb1", "name=front", "ip=203.0.113.1"}
+	back := []string{"id=lb2", "name=back", "ip=203.0.113.2"}
+
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_info", append(front, "region=nyc1", "vpc_uuid=vpc-1", "network=EXTERNAL")...)
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_info", append(back, "region=fra1", "vpc_uuid=vpc-2", "network=INTERNAL")...)
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_status", front...)
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_status", back...)
+	assertMetric(t, mfs, 3, "digitalocean_loadbalancer_droplets", front...)
+	assertMetric(t, mfs, 2, "digitalocean_loadbalancer_healthy_droplets", front...)
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_healthy_droplets", back...)
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_forwarding_rule", append(front, "entry_protocol=https", "entry_port=443", "target_protocol=http", "target_port=8080")...)
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_redirect_http_to_https", front...)
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_redirect_http_to_https", back...)
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_disable_lets_encrypt_dns_records", front...)
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_disable_lets_encrypt_dns_records", back...)
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_enable_proxy_protocol", front...)
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_enable_proxy_protocol", back...)
+	assertMetric(t, mfs, 1, "digitalocean_loadbalancer_enable_backend_keepalive", front...)
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_enable_backend_keepalive", back...)
+	assertMetric(t, mfs, 1577934245, "digitalocean_loadbalancer_created_timestamp_seconds", front...)
+	assertMetric(t, mfs, 10, "digitalocean_loadbalancer_health_check_interval_seconds", front...)
+	assertMetric(t, mfs, 5, "digitalocean_loadbalancer_health_check_timeout_seconds", front...)
+	assertMetric(t, mfs, 3, "digitalocean_loadbalancer_health_check_healthy_threshold", front...)
+	assertMetric(t, mfs, 2, "digitalocean_loadbalancer_health_check_unhealthy_threshold", front...)
+	assertNoMetric(t, mfs, "digitalocean_loadbalancer_health_check_interval_seconds", back...)
+}
+
+func TestLoadBalancerCollectorRegions(t *testing.T) {
+	api := newTestAPI(t, loadBalancerFixtures)
+	c := testConfig(api.client(t))
+	c.Regions = []string{"fra1"}
+	mfs := gather(t, newTestCollector(t, "loadbalancer", c))
+
+	assertNoMetric(t, mfs, "digitalocean_loadbalancer_status", "id=lb1", "name=front", "ip=203.0.113.1")
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_status", "id=lb2", "name=back", "ip=203.0.113.2")
+}
+
+func TestLoadBalancerCollectorDropletsError(t *testing.T) {
+	api := newTestAPI(t, map[string]string{"/v2/load_balancers": loadBalancerFixtures["/v2/load_balancers"]})
+	mfs := gather(t, newTestCollector(t, "loadbalancer", testConfig(api.client(t))))
+
+	// Without the droplets the healthy droplets are unknown, the rest is still collected.
+	front := []string{"id=lb1", "name=front", "ip=203.0.113.1"}
+	assertMetric(t, mfs, 3, "digitalocean_loadbalancer_droplets", front...)
+	assertNoMetric(t, mfs, "digitalocean_loadbalancer_healthy_droplets", front...)
+}
+
+func TestLoadBalancerCollectorDropletsDisabled(t *testing.T) {
+	api := newTestAPI(t, loadBalancerFixtures)
+	c := testConfig(api.client(t))
+	c.Disabled = []string{"droplet"}
+	mfs := gather(t, newTestCollector(t, "loadbalancer", c))
+
+	assertNoMetric(t, mfs, "digitalocean_loadbalancer_healthy_droplets", "id=lb1", "name=front", "ip=203.0.113.1")
+	if n := api.requested("/v2/droplets"); n != 0 {
+		t.Errorf("droplets were listed %d times, want 0", n)
+	}
+}
+
+func TestLoadBalancerCollectorDropletIDs(t *testing.T) {
+	api := newTestAPI(t, loadBalancerFixtures)
+	c := testConfig(api.client(t))
+	c.Cache = NewScrapeCache(c.Client, c.PerPage, []int{1})
+	mfs := gather(t, newTestCollector(t, "loadbalancer", c))
+
+	// Whether the droplets 2 and 3 behind lb1 are active is unknown,
+	// lb2 has no droplets at all.
+	assertNoMetric(t, mfs, "digitalocean_loadbalancer_healthy_droplets", "id=lb1", "name=front", "ip=203.0.113.1")
+	assertMetric(t, mfs, 0, "digitalocean_loadbalancer_healthy_droplets", "id=lb2", "name=back", "ip=203.0.113.2")
+}
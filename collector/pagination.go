@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/digitalocean/godo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MaxPerPage is the largest page size the DigitalOcean API allows.
+const MaxPerPage = 200
+
+// errStopPaging can be returned by a list function to stop paginating early.
+var errStopPaging = errors.New("stop paging")
+
+// paginate calls list with increasing page numbers until the last page was listed.
+// The pages are counted if ctx was returned by withPages.
+func paginate(ctx context.Context, perPage int, list func(opt *godo.ListOptions) (*godo.Response, error)) error {
+	fetched, _ := ctx.Value(pagesKey{}).(*pages)
+
+	opt := &godo.ListOptions{PerPage: perPage}
+	for {
+		resp, err := list(opt)
+		if fetched != nil {
+			atomic.AddUint64(&fetched.count, 1)
+		}
+		if err == errStopPaging {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resp.Links == nil || resp.Links.IsLastPage() {
+			return nil
+		}
+
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return err
+		}
+		opt.Page = page + 1
+	}
+}
+
+// rawPage is embedded by the roots of pages got with getPage for their links.
+type rawPage struct {
+	Links *godo.Links `json:"links"`
+}
+
+func (p *rawPage) links() *godo.Links {
+	return p.Links
+}
+
+// getPage gets the page of the list at path the way godo's List methods do,
+// but decodes the response into root. This way fields of the API the vendored
+// godo doesn't know about can be decoded too.
+func getPage(ctx context.Context, client *godo.Client, path string, opt *godo.ListOptions, root interface{ links() *godo.Links }) (*godo.Response, error) {
+	query := url.Values{"per_page": {strconv.Itoa(opt.PerPage)}}
+	if opt.Page > 0 {
+		query.Set("page", strconv.Itoa(opt.Page))
+	}
+	req, err := client.NewRequest(ctx, http.MethodGet, path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(ctx, req, root)
+	if err != nil {
+		return resp, err
+	}
+	resp.Links = root.links()
+	return resp, nil
+}
+
+type pagesKey struct{}
+
+// pages is the number of pages fetched during a single collection.
+type pages struct {
+	count uint64
+}
+
+// withPages returns a child of ctx whose fetched pages are counted in n.
+func withPages(ctx context.Context, n *pages) context.Context {
+	return context.WithValue(ctx, pagesKey{}, n)
+}
+
+// pageCounter exposes the number of pages a collector fetched from the API
+// during its last collection.
+type pageCounter struct {
+	desc *prometheus.Desc
+}
+
+func newPageCounter(collector string) *pageCounter {
+	return &pageCounter{
+		desc: prometheus.NewDesc(
+			"digitalocean_api_pages_fetched",
+			"Number of pages fetched from the DigitalOcean API during the last collection",
+			nil, prometheus.Labels{"collector": collector},
+		),
+	}
+}
+
+// context returns a child of ctx counting the pages fetched with it.
+func (p *pageCounter) context(ctx context.Context) (context.Context, *pages) {
+	n := &pages{}
+	return withPages(ctx, n), n
+}
+
+// collect sends the number of pages fetched so far.
+func (p *pageCounter) collect(ch chan<- prometheus.Metric, n *pages) {
+	ch <- prometheus.MustNewConstMetric(
+		p.desc,
+		prometheus.GaugeValue,
+		float64(atomic.LoadUint64(&n.count)),
+	)
+}
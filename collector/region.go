@@ -0,0 +1,28 @@
+package collector
+
+import "github.com/digitalocean/godo"
+
+// inRegions reports whether region is one of the regions' slugs.
+// An empty list of regions matches every region.
+func inRegions(regions []string, region *godo.Region) bool {
+	if len(regions) == 0 {
+		return true
+	}
+	if region == nil {
+		return false
+	}
+	return inRegionSlugs(regions, region.Slug)
+}
+
+// inRegionSlugs is inRegions for resources whose region is only a slug.
+func inRegionSlugs(regions []string, slug string) bool {
+	if len(regions) == 0 {
+		return true
+	}
+	for _, region := range regions {
+		if region == slug {
+			return true
+		}
+	}
+	return false
+}
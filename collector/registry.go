@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config has everything collectors of an account are built from.
+type Config struct {
+	Logger  log.Logger
+	Client  *godo.Client
+	Timeout time.Duration
+	PerPage int
+	// Cache shares the droplets, volumes, floating ips and database clusters between the collectors.
+	// They must be gathered with its Gatherer, to list them again on every scrape.
+	Cache *ScrapeCache
+	// Disabled are the names of the collectors not to build. Collectors only
+	// cross-reference the resources of other collectors that aren't disabled.
+	Disabled []string
+	// RejectEmpty rejects empty listings of droplets, volumes and load balancers
+	// if the previous listing wasn't empty.
+	RejectEmpty bool
+	// EmitZero sends aggregate counts of 0 for label values without any resources,
+	// instead of leaving them out.
+	EmitZero bool
+	// AccountLimitUsage lists droplets, floating ips and volumes to expose the ratio of the account's limits they use.
+	AccountLimitUsage bool
+	// Regions to collect regional resources in, all regions if empty.
+	Regions []string
+	// DropletTagKeys are the keys of key:value tags droplets are counted by.
+	DropletTagKeys []string
+	// DropletMaxTagCardinality limits the number of series of droplets by tag, if greater than 0.
+	DropletMaxTagCardinality int
+	// DropletLabelKeys are the keys of key:value tags exposed as labels of digitalocean_droplet_labels.
+	DropletLabelKeys []string
+	// DropletFilter excludes droplets from the droplet collector.
+	DropletFilter DropletFilter
+	// DropletRequiredTags are the tags every droplet should have.
+	DropletRequiredTags []string
+	// DropletInfoOnly exposes a single info metric per droplet.
+	DropletInfoOnly bool
+	// DropletRecentWindow is the window droplets count as created recently in.
+	DropletRecentWindow time.Duration
+	// DropletBackupCounts lists and counts the backups and snapshots of every droplet.
+	DropletBackupCounts bool
+	// DropletNeighbors lists and counts the neighbors of every droplet.
+	DropletNeighbors bool
+	// DomainIncludeRecords counts the records of every domain by their type.
+	DomainIncludeRecords bool
+	// DatabaseMetrics scrapes the metrics endpoints of every database cluster.
+	DatabaseMetrics bool
+	// DatabaseMetricsUsername and DatabaseMetricsPassword are the credentials of the metrics
+	// endpoints. Without a username they're got from the API.
+	DatabaseMetricsUsername string
+	DatabaseMetricsPassword string
+	// DatabaseIncludeDetails lists the read-only replicas, connection pools, users and databases of every database cluster.
+	DatabaseIncludeDetails bool
+	// Functions collects the Functions namespaces and their triggers.
+	Functions bool
+	// Billing collects the balance of the account and the cost of the current month's invoice
+	// items matching BillingSpacesMatch and BillingBandwidthMatch.
+	Billing               bool
+	BillingSpacesMatch    *regexp.Regexp
+	BillingBandwidthMatch *regexp.Regexp
+	// Uptime collects the uptime checks and their latest state.
+	Uptime bool
+	// FloatingIPLastAction lists the actions of every floating ip.
+	FloatingIPLastAction bool
+	// VPCIncludeMembers lists the members of every VPC.
+	VPCIncludeMembers bool
+	// SnapshotPricePerGB is the monthly price in dollars per GB of snapshot storage.
+	SnapshotPricePerGB float64
+}
+
+// enabled reports whether the collector with the name isn't disabled.
+func (c Config) enabled(name string) bool {
+	for _, disabled := range c.Disabled {
+		if disabled == name {
+			return false
+		}
+	}
+	return true
+}
+
+// Factory returns a new collector built from the Config,
+// or nil if the Config doesn't enable the collector.
+type Factory func(c Config) prometheus.Collector
+
+var (
+	factoriesMu sync.Mutex
+	factories   = map[string]Factory{}
+)
+
+// RegisterCollector makes a collector available under name, so that it's
+// returned by Collectors. It panics if the name is already registered.
+func RegisterCollector(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("collector %q is already registered", name))
+	}
+	factories[name] = factory
+}
+
+// Collectors returns all registered collectors that aren't disabled built from the Config, keyed by their name.
+func Collectors(c Config) map[string]prometheus.Collector {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	collectors := make(map[string]prometheus.Collector, len(factories))
+	for name, factory := range factories {
+		if !c.enabled(name) {
+			continue
+		}
+		if collector := factory(c); collector != nil {
+			collectors[name] = collector
+		}
+	}
+	return collectors
+}
+
+// Names returns the sorted names of all registered collectors.
+func Names() []string {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/digitalocean/godo"
+)
+
+// Resources is the inventory of an account's resources as listed by the collectors.
+type Resources struct {
+	Droplets      []godo.Droplet    `json:"droplets"`
+	Domains       []godo.Domain     `json:"domains"`
+	FloatingIPs   []godo.FloatingIP `json:"floating_ips"`
+	Images        []godo.Image      `json:"images"`
+	Keys          []godo.Key        `json:"keys"`
+	LoadBalancers []apiLoadBalancer `json:"load_balancers"`
+	Snapshots     []godo.Snapshot   `json:"snapshots"`
+	Volumes       []godo.Volume     `json:"volumes"`
+}
+
+// ListResources lists all resources of the Config the way the collectors do: with its regions,
+// droplet IDs and droplet filter, and the droplets, floating ips and volumes from its cache.
+// Like a scrape, the lists cached by the previous one are forgotten first.
+// Each call gets its own timeout, just like a collector does on every scrape.
+func ListResources(c Config) (*Resources, error) {
+	c.Cache.reset()
+	var r Resources
+
+	calls := []func(ctx context.Context) error{
+		func(ctx context.Context) error {
+			scope, err := c.Cache.droplets(ctx)
+			if err != nil {
+				return err
+			}
+			for _, droplet := range scope.droplets {
+				if inRegions(c.Regions, droplet.Region) && !c.DropletFilter.excludes(droplet) {
+					r.Droplets = append(r.Droplets, droplet)
+				}
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) { r.Domains, err = listDomains(ctx, c.Client, c.PerPage); return },
+		func(ctx context.Context) error {
+			floatingIPs, err := c.Cache.floatingIPs(ctx)
+			for _, ip := range floatingIPs {
+				if inRegions(c.Regions, ip.Region) {
+					r.FloatingIPs = append(r.FloatingIPs, ip)
+				}
+			}
+			return err
+		},
+		func(ctx context.Context) (err error) { r.Images, err = listImages(ctx, c.Client, c.PerPage); return },
+		func(ctx context.Context) (err error) { r.Keys, err = listKeys(ctx, c.Client, c.PerPage); return },
+		func(ctx context.Context) error {
+			lbs, err := listLoadBalancers(ctx, c.Client, c.PerPage)
+			for _, lb := range lbs {
+				if inRegions(c.Regions, lb.Region) {
+					r.LoadBalancers = append(r.LoadBalancers, lb)
+				}
+			}
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			r.Snapshots, err = listSnapshots(ctx, c.Client, c.PerPage)
+			return
+		},
+		func(ctx context.Context) error {
+			volumes, err := c.Cache.volumes(ctx)
+			for _, vol := range volumes {
+				if inRegions(c.Regions, vol.Region) {
+					r.Volumes = append(r.Volumes, vol)
+				}
+			}
+			return err
+		},
+	}
+
+	for _, call := range calls {
+		ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+		err := call(ctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"regexp"
+	"testing"
+)
+
+var resourcesFixtures = map[string]string{
+	"/v2/droplets": `{"droplets":[
+		{"id":1,"name":"web-1","region":{"slug":"nyc1"}},
+		{"id":2,"name":"tmp-1","region":{"slug":"nyc1"}},
+		{"id":3,"name":"web-2","region":{"slug":"fra1"}}
+	]}`,
+	"/v2/droplets/3":   `{"droplet":{"id":3,"name":"web-2","region":{"slug":"fra1"}}}`,
+	"/v2/domains":      `{"domains":[{"name":"example.com"}]}`,
+	"/v2/floating_ips": `{"floating_ips":[{"ip":"203.0.113.1","region":{"slug":"nyc1"}},{"ip":"203.0.113.2","region":{"slug":"fra1"}}]}`,
+	"/v2/images":       `{"images":[]}`,
+	"/v2/account/keys": `{"ssh_keys":[]}`,
+	"/v2/load_balancers": `{"load_balancers":[
+		{"id":"lb1","name":"web","region":{"slug":"nyc1"},"vpc_uuid":"v1","network":"EXTERNAL"},
+		{"id":"lb2","name":"api","region":{"slug":"fra1"},"network":"INTERNAL"}
+	]}`,
+	"/v2/snapshots": `{"snapshots":[]}`,
+	"/v2/volumes":   `{"volumes":[{"id":"vol1","name":"data","region":{"slug":"fra1"}}]}`,
+}
+
+func TestListResources(t *testing.T) {
+	api := newTestAPI(t, resourcesFixtures)
+	c := testConfig(api.client(t))
+	c.Regions = []string{"nyc1"}
+	c.DropletFilter = DropletFilter{NameExclude: regexp.MustCompile("^tmp-")}
+
+	r, err := ListResources(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Droplets) != 1 || r.Droplets[0].ID != 1 {
+		t.Errorf("droplets = %v, want only droplet 1", r.Droplets)
+	}
+	if len(r.FloatingIPs) != 1 || r.FloatingIPs[0].IP != "203.0.113.1" {
+		t.Errorf("floating ips = %v, want only 203.0.113.1", r.FloatingIPs)
+	}
+	if len(r.Volumes) != 0 {
+		t.Errorf("volumes = %v, want none", r.Volumes)
+	}
+	// The load balancers have the fields the vendored godo doesn't decode.
+	if len(r.LoadBalancers) != 1 || r.LoadBalancers[0].VPCUUID != "v1" || r.LoadBalancers[0].Network != "EXTERNAL" {
+		t.Errorf("load balancers = %v, want only lb1 with its VPC and network", r.LoadBalancers)
+	}
+}
+
+func TestListResourcesDropletIDs(t *testing.T) {
+	api := newTestAPI(t, resourcesFixtures)
+	c := testConfig(api.client(t))
+	c.Cache = NewScrapeCache(c.Client, c.PerPage, []int{3})
+
+	r, err := ListResources(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Droplets) != 1 || r.Droplets[0].ID != 3 {
+		t.Errorf("droplets = %v, want only droplet 3", r.Droplets)
+	}
+	if n := api.requested("/v2/droplets"); n != 0 {
+		t.Errorf("droplets were listed %d times, want 0", n)
+	}
+}
@@ -2,6 +2,8 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/digitalocean/godo"
@@ -10,23 +12,51 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("snapshot", func(c Config) prometheus.Collector {
+		return NewSnapshotCollector(c)
+	})
+}
+
 // SnapshotCollector collects metrics about all snapshots of droplets & volumes.
 type SnapshotCollector struct {
-	logger  log.Logger
-	client  *godo.Client
-	timeout time.Duration
+	logger     log.Logger
+	client     *godo.Client
+	timeout    time.Duration
+	perPage    int
+	pricePerGB float64
+	// emitZero sends an estimated cost of 0 for resource types without snapshots.
+	emitZero bool
+	cache    *ScrapeCache
+	orphans  bool
+	*lastSuccess
+	pages *pageCounter
 
-	Size        *prometheus.Desc
-	MinDiskSize *prometheus.Desc
+	Size                 *prometheus.Desc
+	MinDiskSize          *prometheus.Desc
+	EstimatedMonthlyCost *prometheus.Desc
+	Orphaned             *prometheus.Desc
+	Created24h           *prometheus.Desc
+	OldestAge            *prometheus.Desc
 }
 
-// NewSnapshotCollector returns a new SnapshotCollector.
-func NewSnapshotCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *SnapshotCollector {
+// NewSnapshotCollector returns a new SnapshotCollector built from the Config.
+// Their cost is estimated with the SnapshotPricePerGB. Unless the droplet or volume collector
+// is disabled the droplets and volumes are got from the cache, to tell the snapshots
+// of resources that don't exist anymore.
+func NewSnapshotCollector(c Config) *SnapshotCollector {
 	labels := []string{"id", "name", "region", "type"}
 	return &SnapshotCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		pricePerGB:  c.SnapshotPricePerGB,
+		emitZero:    c.EmitZero,
+		cache:       c.Cache,
+		orphans:     c.enabled("droplet") && c.enabled("volume"),
+		lastSuccess: newLastSuccess("snapshot"),
+		pages:       newPageCounter("snapshot"),
 
 		Size: prometheus.NewDesc(
 			"digitalocean_snapshot_size_bytes",
@@ -38,6 +68,22 @@ func NewSnapshotCollector(logger log.Logger, client *godo.Client, timeout time.D
 			"Minimum disk size for a droplet/volume to run this snapshot on in bytes",
 			labels, nil,
 		),
+		EstimatedMonthlyCost: prometheus.NewDesc(
+			"digitalocean_snapshot_estimated_monthly_cost_usd",
+			"Estimated monthly cost in dollars of all snapshots' storage, by resource type",
+			[]string{"type"}, nil,
+		),
+		Orphaned: prometheus.NewDesc(
+			"digitalocean_snapshot_orphaned",
+			"If 1 the droplet/volume the snapshot was taken from doesn't exist anymore, 0 otherwise",
+			labels, nil,
+		),
+		Created24h: newCreated24hDesc("snapshot"),
+		OldestAge: prometheus.NewDesc(
+			"digitalocean_snapshot_oldest_age_seconds",
+			"Age in seconds of the oldest snapshot of a droplet/volume",
+			[]string{"type", "resource_id"}, nil,
+		),
 	}
 }
 
@@ -45,22 +91,62 @@ func NewSnapshotCollector(logger log.Logger, client *godo.Client, timeout time.D
 // collected by this Collector.
 func (c *SnapshotCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.Size
+	ch <- c.MinDiskSize
+	ch <- c.EstimatedMonthlyCost
+	ch <- c.Orphaned
+	ch <- c.Created24h
+	ch <- c.OldestAge
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *SnapshotCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
-	snapshots, _, err := c.client.Snapshots.List(ctx, nil)
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	snapshots, err := listSnapshots(ctx, c.client, c.perPage)
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't list snapshots",
 			"err", err,
 		)
+		c.lastSuccess.collect(ch, false)
 		return
 	}
 
+	// Without all droplets and volumes orphaned snapshots can't be told apart.
+	var resources *snapshotResources
+	if c.orphans {
+		resources, err = c.snapshotResources(ctx)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list snapshot resources",
+				"err", err,
+			)
+		}
+	}
+	c.lastSuccess.collect(ch, err == nil)
+
+	var created int
+	sizeByType := map[string]float64{}
+	if c.emitZero {
+		sizeByType["droplet"], sizeByType["volume"] = 0, 0
+	}
+	oldest := map[[2]string]time.Time{}
 	for _, snapshot := range snapshots {
+		sizeByType[snapshot.ResourceType] += snapshot.SizeGigaBytes
+		if createdWithin24hRFC3339(snapshot.Created) {
+			created++
+		}
+		if t, err := time.Parse(time.RFC3339, snapshot.Created); err == nil {
+			resource := [2]string{snapshot.ResourceType, snapshot.ResourceID}
+			if o, ok := oldest[resource]; !ok || t.Before(o) {
+				oldest[resource] = t
+			}
+		}
+
 		labels := []string{
 			snapshot.ID,
 			snapshot.Name,
@@ -83,5 +169,94 @@ func (c *SnapshotCollector) Collect(ch chan<- prometheus.Metric) {
 				labels...,
 			)
 		}
+
+		if resources != nil {
+			if exists, known := resources.exist(snapshot); known {
+				var orphaned float64
+				if !exists {
+					orphaned = 1.0
+				}
+				ch <- prometheus.MustNewConstMetric(
+					c.Orphaned,
+					prometheus.GaugeValue,
+					orphaned,
+					labels...,
+				)
+			}
+		}
+	}
+
+	for resourceType, size := range sizeByType {
+		ch <- prometheus.MustNewConstMetric(
+			c.EstimatedMonthlyCost,
+			prometheus.GaugeValue,
+			size*c.pricePerGB,
+			resourceType,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.Created24h,
+		prometheus.GaugeValue,
+		float64(created),
+	)
+	for resource, t := range oldest {
+		ch <- prometheus.MustNewConstMetric(
+			c.OldestAge,
+			prometheus.GaugeValue,
+			time.Since(t).Seconds(),
+			resource[0], resource[1],
+		)
+	}
+}
+
+// snapshotResources are the droplets and volumes snapshots can be taken from.
+type snapshotResources struct {
+	droplets *dropletScope
+	// ids are the droplets and volumes keyed by their resource type and id
+	// like the snapshots reference them, e.g. "droplet/123".
+	ids map[string]bool
+}
+
+// exist reports whether the resource the snapshot was taken from exists,
+// and whether that's known. Snapshots of droplets outside the configured
+// droplet IDs may have been taken from any droplet, existing or not.
+func (r *snapshotResources) exist(snapshot godo.Snapshot) (bool, bool) {
+	if snapshot.ResourceType == "droplet" {
+		id, err := strconv.Atoi(snapshot.ResourceID)
+		if err != nil || !r.droplets.known(id) {
+			return false, false
+		}
 	}
+	return r.ids[snapshot.ResourceType+"/"+snapshot.ResourceID], true
+}
+
+// snapshotResources returns the droplets and volumes snapshots can be taken from.
+func (c *SnapshotCollector) snapshotResources(ctx context.Context) (*snapshotResources, error) {
+	droplets, err := c.cache.droplets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := c.cache.volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(droplets.droplets)+len(volumes))
+	for _, droplet := range droplets.droplets {
+		ids[fmt.Sprintf("droplet/%d", droplet.ID)] = true
+	}
+	for _, vol := range volumes {
+		ids["volume/"+vol.ID] = true
+	}
+	return &snapshotResources{droplets: droplets, ids: ids}, nil
+}
+
+func listSnapshots(ctx context.Context, client *godo.Client, perPage int) ([]godo.Snapshot, error) {
+	var snapshots []godo.Snapshot
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.Snapshots.List(ctx, opt)
+		snapshots = append(snapshots, page...)
+		return resp, err
+	})
+	return snapshots, err
 }
@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatusPageURL is the components endpoint of DigitalOcean's public status page.
+const StatusPageURL = "https://status.digitalocean.com/api/v2/components.json"
+
+// componentStatuses maps the status of a status page component to the
+// value of digitalocean_platform_status, higher is worse.
+var componentStatuses = map[string]float64{
+	"operational":          0,
+	"under_maintenance":    1,
+	"degraded_performance": 2,
+	"partial_outage":       3,
+	"major_outage":         4,
+}
+
+// StatusPageCollector collects the status of DigitalOcean's platform from its public status page.
+// It isn't specific to any account.
+type StatusPageCollector struct {
+	logger log.Logger
+	client *http.Client
+	url    string
+	*lastSuccess
+
+	Status *prometheus.Desc
+}
+
+// NewStatusPageCollector returns a new StatusPageCollector getting the components from url.
+func NewStatusPageCollector(logger log.Logger, client *http.Client, url string) *StatusPageCollector {
+	return &StatusPageCollector{
+		logger:      logger,
+		client:      client,
+		url:         url,
+		lastSuccess: newLastSuccess("status_page"),
+
+		Status: prometheus.NewDesc(
+			"digitalocean_platform_status",
+			"Status of the component on DigitalOcean's status page, 0 operational, 1 under maintenance, 2 degraded performance, 3 partial outage, 4 major outage",
+			[]string{"component"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *StatusPageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Status
+	ch <- c.lastSuccess.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *StatusPageCollector) Collect(ch chan<- prometheus.Metric) {
+	components, err := c.components()
+	c.lastSuccess.collect(ch, err == nil)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't get status page components",
+			"err", err,
+		)
+		return
+	}
+
+	for name, status := range components {
+		value, ok := componentStatuses[status]
+		if !ok {
+			level.Debug(c.logger).Log("msg", "unknown status page component status", "component", name, "status", status)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Status,
+			prometheus.GaugeValue,
+			value,
+			name,
+		)
+	}
+}
+
+// components returns the status of every component keyed by its name.
+// Components in a group are named after the group, like "Droplets/AMS3",
+// as the same name is used in several groups.
+func (c *StatusPageCollector) components() (map[string]string, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var page struct {
+		Components []struct {
+			ID      string `json:"id"`
+			Name    string `json:"name"`
+			Status  string `json:"status"`
+			GroupID string `json:"group_id"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]string)
+	for _, component := range page.Components {
+		groups[component.ID] = component.Name
+	}
+
+	components := make(map[string]string, len(page.Components))
+	for _, component := range page.Components {
+		name := component.Name
+		if group, ok := groups[component.GroupID]; ok {
+			name = group + "/" + name
+		}
+		components[name] = component.Status
+	}
+	return components, nil
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// lastSuccess remembers when a collector last collected without any error,
+// and how many collections failed since. Collectors embed it, so that
+// ConsecutiveFailures can be asked about them.
+type lastSuccess struct {
+	desc     *prometheus.Desc
+	mu       sync.Mutex
+	ts       time.Time
+	failures int
+}
+
+func newLastSuccess(collector string) *lastSuccess {
+	return &lastSuccess{
+		desc: prometheus.NewDesc(
+			"digitalocean_collector_last_success_timestamp_seconds",
+			"Unix timestamp of the last collection without errors",
+			nil, prometheus.Labels{"collector": collector},
+		),
+	}
+}
+
+// collect updates the timestamp if the collection succeeded and sends it,
+// once the collector has succeeded at least once.
+func (l *lastSuccess) collect(ch chan<- prometheus.Metric, succeeded bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if succeeded {
+		l.ts = time.Now()
+		l.failures = 0
+	} else {
+		l.failures++
+	}
+	if l.ts.IsZero() {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		l.desc,
+		prometheus.GaugeValue,
+		float64(l.ts.Unix()),
+	)
+}
+
+func (l *lastSuccess) consecutiveFailures() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.failures
+}
+
+// ConsecutiveFailures returns the number of the collector's last collections that failed in a row.
+// It returns false if c isn't one of this package's collectors.
+func ConsecutiveFailures(c prometheus.Collector) (int, bool) {
+	f, ok := c.(interface{ consecutiveFailures() int })
+	if !ok {
+		return 0, false
+	}
+	return f.consecutiveFailures(), true
+}
@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("tag", func(c Config) prometheus.Collector {
+		return NewTagCollector(c)
+	})
+}
+
+// TagCollector collects metrics about the tags of the account.
+type TagCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	*lastSuccess
+	pages *pageCounter
+
+	Empty *prometheus.Desc
+}
+
+// NewTagCollector returns a new TagCollector built from the Config.
+func NewTagCollector(c Config) *TagCollector {
+	return &TagCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		lastSuccess: newLastSuccess("tag"),
+		pages:       newPageCounter("tag"),
+
+		Empty: prometheus.NewDesc(
+			"digitalocean_tag_empty",
+			"If 1 the tag isn't attached to any resource, 0 otherwise",
+			[]string{"tag"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *TagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Empty
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *TagCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	tags, err := listTags(ctx, c.client, c.perPage)
+	c.lastSuccess.collect(ch, err == nil)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list tags",
+			"err", err,
+		)
+		return
+	}
+
+	for _, tag := range tags {
+		empty := 1.0
+		if tag.Resources.Count > 0 || tag.Resources.Droplets.Count > 0 {
+			empty = 0
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Empty,
+			prometheus.GaugeValue,
+			empty,
+			tag.Name,
+		)
+	}
+}
+
+// tagWithCount is a tag with the number of all resources it's attached to.
+// godo only decodes the number of its droplets, not of its volumes,
+// images and other resources.
+type tagWithCount struct {
+	Name      string `json:"name"`
+	Resources struct {
+		Count    int `json:"count"`
+		Droplets struct {
+			Count int `json:"count"`
+		} `json:"droplets"`
+	} `json:"resources"`
+}
+
+// listTags lists all tags with the number of resources they're attached to.
+func listTags(ctx context.Context, client *godo.Client, perPage int) ([]tagWithCount, error) {
+	var tags []tagWithCount
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Tags []tagWithCount `json:"tags"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/tags", opt, &root)
+		tags = append(tags, root.Tags...)
+		return resp, err
+	})
+	return tags, err
+}
@@ -0,0 +1,21 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestTagCollector(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/tags": `{"tags":[
+			{"name":"web","resources":{"count":2,"droplets":{"count":2},"volumes":{"count":0}}},
+			{"name":"data","resources":{"count":1,"droplets":{"count":0},"volumes":{"count":1}}},
+			{"name":"unused","resources":{"count":0,"droplets":{"count":0}}}
+		],"links":{}}`,
+	})
+	mfs := gather(t, newTestCollector(t, "tag", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 0, "digitalocean_tag_empty", "tag=web")
+	// Tags of volumes only aren't attached to droplets, but still not empty.
+	assertMetric(t, mfs, 0, "digitalocean_tag_empty", "tag=data")
+	assertMetric(t, mfs, 1, "digitalocean_tag_empty", "tag=unused")
+}
@@ -0,0 +1,199 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("uptime", func(c Config) prometheus.Collector {
+		if !c.Uptime {
+			return nil
+		}
+		return NewUptimeCollector(c)
+	})
+}
+
+// uptimeCheck is an uptime check, which the vendored godo doesn't know about.
+type uptimeCheck struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Type is one of ping, http or https.
+	Type    string `json:"type"`
+	Target  string `json:"target"`
+	Enabled bool   `json:"enabled"`
+}
+
+// uptimeCheckRegionState is the latest state of an uptime check in one of the regions it's checked from.
+type uptimeCheckRegionState struct {
+	// Status is UP or DOWN.
+	Status                    string  `json:"status"`
+	ThirtyDayUptimePercentage float64 `json:"thirty_day_uptime_percentage"`
+}
+
+// UptimeCollector collects metrics about the uptime checks of the account.
+type UptimeCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	*lastSuccess
+	pages *pageCounter
+
+	Enabled *prometheus.Desc
+	Up      *prometheus.Desc
+	Uptime  *prometheus.Desc
+}
+
+// NewUptimeCollector returns a new UptimeCollector built from the Config.
+// The state of every enabled check is got, which is one more API call per check.
+func NewUptimeCollector(c Config) *UptimeCollector {
+	labels := []string{"id", "name"}
+
+	return &UptimeCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		lastSuccess: newLastSuccess("uptime"),
+		pages:       newPageCounter("uptime"),
+
+		Enabled: prometheus.NewDesc(
+			"digitalocean_uptime_check_enabled",
+			"If 1 the uptime check is enabled, 0 otherwise",
+			append(labels, "type", "target"), nil,
+		),
+		Up: prometheus.NewDesc(
+			"digitalocean_uptime_check_up",
+			"If 1 the target of the uptime check was up when it was last checked from the region, 0 if it was down",
+			append(labels, "region"), nil,
+		),
+		Uptime: prometheus.NewDesc(
+			"digitalocean_uptime_check_uptime_30d_ratio",
+			"Ratio of the last 30 days the target of the uptime check was up when checked from the region",
+			append(labels, "region"), nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *UptimeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Enabled
+	ch <- c.Up
+	ch <- c.Uptime
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *UptimeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	checks, err := listUptimeChecks(ctx, c.client, c.perPage)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list uptime checks",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	succeeded := true
+	for _, check := range checks {
+		var enabled float64
+		if check.Enabled {
+			enabled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.Enabled,
+			prometheus.GaugeValue,
+			enabled,
+			check.ID, check.Name, check.Type, check.Target,
+		)
+
+		// Disabled checks don't check their target, their state is outdated.
+		if !check.Enabled {
+			continue
+		}
+
+		// The state shares the collector's timeout with the checks.
+		regions, err := getUptimeCheckState(ctx, c.client, check.ID)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't get state of uptime check",
+				"check", check.Name,
+				"err", err,
+			)
+			succeeded = false
+			continue
+		}
+		for region, state := range regions {
+			var up float64
+			switch state.Status {
+			case "UP":
+				up = 1
+			case "DOWN":
+			default:
+				// A new check has no state in the regions it wasn't checked from yet.
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.Up,
+				prometheus.GaugeValue,
+				up,
+				check.ID, check.Name, region,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				c.Uptime,
+				prometheus.GaugeValue,
+				state.ThirtyDayUptimePercentage/100,
+				check.ID, check.Name, region,
+			)
+		}
+	}
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+func listUptimeChecks(ctx context.Context, client *godo.Client, perPage int) ([]uptimeCheck, error) {
+	var checks []uptimeCheck
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Checks []uptimeCheck `json:"checks"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/uptime/checks", opt, &root)
+		checks = append(checks, root.Checks...)
+		return resp, err
+	})
+	return checks, err
+}
+
+// getUptimeCheckState returns the latest state of the uptime check with the id
+// by the regions it's checked from, like us_east.
+func getUptimeCheckState(ctx context.Context, client *godo.Client, id string) (map[string]uptimeCheckRegionState, error) {
+	req, err := client.NewRequest(ctx, http.MethodGet, "v2/uptime/checks/"+id+"/state", nil)
+	if err != nil {
+		return nil, err
+	}
+	var root struct {
+		State struct {
+			Regions map[string]uptimeCheckRegionState `json:"regions"`
+		} `json:"state"`
+	}
+	if _, err := client.Do(ctx, req, &root); err != nil {
+		return nil, err
+	}
+	return root.State.Regions, nil
+}
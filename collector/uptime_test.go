@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestUptimeCollector(t *testing.T) {
+	api := newTestAPI(t, map[string]string{
+		"/v2/uptime/checks": `{"checks":[
+			{"id":"u1","name":"web","type":"https","target":"https://example.com","regions":["us_east","eu_west"],"enabled":true},
+			{"id":"u2","name":"old","type":"ping","target":"203.0.113.1","regions":["us_east"],"enabled":false}
+		],"links":{}}`,
+		"/v2/uptime/checks/u1/state": `{"state":{"regions":{
+			"us_east":{"status":"UP","thirty_day_uptime_percentage":99.5},
+			"eu_west":{"status":"DOWN","thirty_day_uptime_percentage":97},
+			"se_asia":{"status":"","thirty_day_uptime_percentage":0}
+		}}}`,
+	})
+	c := testConfig(api.client(t))
+	c.Uptime = true
+	mfs := gather(t, newTestCollector(t, "uptime", c))
+
+	assertMetric(t, mfs, 1, "digitalocean_uptime_check_enabled", "id=u1", "name=web", "type=https", "target=https://example.com")
+	assertMetric(t, mfs, 0, "digitalocean_uptime_check_enabled", "id=u2", "name=old", "type=ping", "target=203.0.113.1")
+	assertMetric(t, mfs, 1, "digitalocean_uptime_check_up", "id=u1", "name=web", "region=us_east")
+	assertMetric(t, mfs, 0, "digitalocean_uptime_check_up", "id=u1", "name=web", "region=eu_west")
+	assertMetric(t, mfs, 0.995, "digitalocean_uptime_check_uptime_30d_ratio", "id=u1", "name=web", "region=us_east")
+	// Regions the check wasn't checked from yet have no state.
+	assertNoMetric(t, mfs, "digitalocean_uptime_check_up", "id=u1", "name=web", "region=se_asia")
+
+	// Disabled checks' state is outdated, it isn't got.
+	if n := api.requested("/v2/uptime/checks/u2/state"); n != 0 {
+		t.Errorf("the state of the disabled check was got %d times, want 0", n)
+	}
+}
+
+func TestUptimeCollectorDisabled(t *testing.T) {
+	api := newTestAPI(t, nil)
+	if _, ok := Collectors(testConfig(api.client(t)))["uptime"]; ok {
+		t.Error("the uptime collector was built without Uptime")
+	}
+}
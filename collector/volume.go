@@ -10,28 +10,73 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	RegisterCollector("volume", func(c Config) prometheus.Collector {
+		return NewVolumeCollector(c)
+	})
+}
+
 // VolumeCollector collects metrics about all volumes.
 type VolumeCollector struct {
 	logger  log.Logger
 	client  *godo.Client
 	timeout time.Duration
+	perPage int
+	regions []string
+	cache   *ScrapeCache
+	// mismatch is whether the region mismatch is collected from the droplets of the cache.
+	mismatch bool
+	// emitZero sends 0 volumes for regions without any.
+	emitZero bool
+	*lastSuccess
+	pages *pageCounter
+	empty *emptyGuard
 
-	Size *prometheus.Desc
+	Size           *prometheus.Desc
+	RegionMismatch *prometheus.Desc
+	TotalSize      *prometheus.Desc
+	ByRegion       *prometheus.Desc
+	Created24h     *prometheus.Desc
 }
 
-// NewVolumeCollector returns a new VolumeCollector.
-func NewVolumeCollector(logger log.Logger, client *godo.Client, timeout time.Duration) *VolumeCollector {
+// NewVolumeCollector returns a new VolumeCollector built from the Config.
+// The volumes are got from the cache.
+func NewVolumeCollector(c Config) *VolumeCollector {
 	labels := []string{"id", "name", "region"}
 	return &VolumeCollector{
-		logger:  logger,
-		client:  client,
-		timeout: timeout,
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		cache:       c.Cache,
+		mismatch:    c.enabled("droplet"),
+		emitZero:    c.EmitZero,
+		lastSuccess: newLastSuccess("volume"),
+		pages:       newPageCounter("volume"),
+		empty:       newEmptyGuard("volume", c.RejectEmpty),
 
 		Size: prometheus.NewDesc(
 			"digitalocean_volume_size_bytes",
 			"Volume's size in bytes",
 			labels, nil,
 		),
+		RegionMismatch: prometheus.NewDesc(
+			"digitalocean_volume_region_mismatch",
+			"If 1 the volume is attached to a droplet in another region, 0 otherwise",
+			labels, nil,
+		),
+		TotalSize: prometheus.NewDesc(
+			"digitalocean_volumes_size_bytes",
+			"Size of all volumes in bytes",
+			nil, nil,
+		),
+		ByRegion: prometheus.NewDesc(
+			"digitalocean_volumes_by_region",
+			"Number of volumes by region",
+			[]string{"region"}, nil,
+		),
+		Created24h: newCreated24hDesc("volume"),
 	}
 }
 
@@ -39,22 +84,77 @@ func NewVolumeCollector(logger log.Logger, client *godo.Client, timeout time.Dur
 // collected by this Collector.
 func (c *VolumeCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.Size
+	ch <- c.RegionMismatch
+	ch <- c.TotalSize
+	ch <- c.ByRegion
+	ch <- c.Created24h
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+	ch <- c.empty.desc
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *VolumeCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
-	volumes, _, err := c.client.Storage.ListVolumes(ctx, nil)
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+	volumes, err := c.cache.volumes(ctx)
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "can't list volumes",
 			"err", err,
 		)
+		c.lastSuccess.collect(ch, false)
 		return
 	}
+	volumes = c.empty.filter(volumes, len(volumes)).([]godo.Volume)
+	c.empty.collect(ch)
 
+	var (
+		dropletRegions *dropletRegions
+		dropletsErr    error
+	)
+	if c.mismatch {
+		dropletRegions, dropletsErr = c.dropletRegions(ctx)
+		if dropletsErr != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list droplets",
+				"err", dropletsErr,
+			)
+		}
+	}
+
+	byRegion := map[string]int{}
+	var regionsErr error
+	if c.emitZero {
+		var regions []string
+		regions, regionsErr = c.zeroRegions(ctx)
+		if regionsErr != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list regions",
+				"err", regionsErr,
+			)
+		}
+		for _, region := range regions {
+			byRegion[region] = 0
+		}
+	}
+	c.lastSuccess.collect(ch, dropletsErr == nil && regionsErr == nil)
+
+	var totalSize float64
+	var created int
 	for _, vol := range volumes {
+		if !inRegions(c.regions, vol.Region) {
+			continue
+		}
+
+		totalSize += float64(vol.SizeGigaBytes * 1024 * 1024 * 1024)
+		byRegion[vol.Region.Slug]++
+		if createdWithin24h(vol.CreatedAt) {
+			created++
+		}
+
 		labels := []string{
 			vol.ID,
 			vol.Name,
@@ -67,5 +167,104 @@ func (c *VolumeCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(vol.SizeGigaBytes*1024*1024*1024),
 			labels...,
 		)
+
+		if mismatch, ok := dropletRegions.mismatch(vol); ok {
+			ch <- prometheus.MustNewConstMetric(
+				c.RegionMismatch,
+				prometheus.GaugeValue,
+				mismatch,
+				labels...,
+			)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.TotalSize,
+		prometheus.GaugeValue,
+		totalSize,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.Created24h,
+		prometheus.GaugeValue,
+		float64(created),
+	)
+	for region, count := range byRegion {
+		ch <- prometheus.MustNewConstMetric(
+			c.ByRegion,
+			prometheus.GaugeValue,
+			float64(count),
+			region,
+		)
+	}
+}
+
+// zeroRegions returns the regions volumes are counted in even without any,
+// the collector's regions or else all available regions.
+func (c *VolumeCollector) zeroRegions(ctx context.Context) ([]string, error) {
+	if len(c.regions) > 0 {
+		return c.regions, nil
+	}
+
+	var regions []string
+	err := paginate(ctx, c.perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := c.client.Regions.List(ctx, opt)
+		for _, region := range page {
+			if region.Available {
+				regions = append(regions, region.Slug)
+			}
+		}
+		return resp, err
+	})
+	return regions, err
+}
+
+// dropletRegions are the region slugs of the droplets the exporter collects by their ID.
+type dropletRegions struct {
+	scope   *dropletScope
+	regions map[int]string
+}
+
+// mismatch returns 1 if the volume is attached to a droplet in another region, 0 otherwise.
+// It's unknown if the droplets weren't listed or the volume is attached to a droplet
+// the exporter doesn't collect.
+func (r *dropletRegions) mismatch(vol godo.Volume) (float64, bool) {
+	if r == nil {
+		return 0, false
 	}
+	var mismatch float64
+	for _, id := range vol.DropletIDs {
+		if !r.scope.known(id) {
+			return 0, false
+		}
+		if region, ok := r.regions[id]; ok && region != vol.Region.Slug {
+			mismatch = 1.0
+		}
+	}
+	return mismatch, true
+}
+
+// dropletRegions returns the region slugs of the droplets of the cache.
+func (c *VolumeCollector) dropletRegions(ctx context.Context) (*dropletRegions, error) {
+	scope, err := c.cache.droplets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	regions := &dropletRegions{scope: scope, regions: make(map[int]string, len(scope.droplets))}
+	for _, droplet := range scope.droplets {
+		if droplet.Region != nil {
+			regions.regions[droplet.ID] = droplet.Region.Slug
+		}
+	}
+	return regions, nil
+}
+
+func listVolumes(ctx context.Context, client *godo.Client, perPage int) ([]godo.Volume, error) {
+	var volumes []godo.Volume
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		page, resp, err := client.Storage.ListVolumes(ctx, &godo.ListVolumeParams{ListOptions: opt})
+		volumes = append(volumes, page...)
+		return resp, err
+	})
+	return volumes, err
 }
@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"testing"
+)
+
+var volumeFixtures = map[string]string{
+	"/v2/volumes": `{"volumes":[
+		{"id":"v1","name":"data","region":{"slug":"nyc1"},"size_gigabytes":10,"droplet_ids":[1]},
+		{"id":"v2","name":"logs","region":{"slug":"nyc1"},"size_gigabytes":20,"droplet_ids":[2]},
+		{"id":"v3","name":"spare","region":{"slug":"fra1"},"size_gigabytes":30,"droplet_ids":[]}
+	],"links":{}}`,
+	"/v2/droplets": `{"droplets":[
+		{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{}},
+		{"id":2,"name":"db","region":{"slug":"fra1"},"status":"active","size":{}}
+	],"links":{}}`,
+	"/v2/droplets/1": `{"droplet":{"id":1,"name":"web","region":{"slug":"nyc1"},"status":"active","size":{}}}`,
+}
+
+func TestVolumeCollector(t *testing.T) {
+	api := newTestAPI(t, volumeFixtures)
+	mfs := gather(t, newTestCollector(t, "volume", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 10*1024*1024*1024, "digitalocean_volume_size_bytes", "id=v1", "name=data", "region=nyc1")
+	assertMetric(t, mfs, 0, "digitalocean_volume_region_mismatch", "id=v1", "name=data", "region=nyc1")
+	assertMetric(t, mfs, 1, "digitalocean_volume_region_mismatch", "id=v2", "name=logs", "region=nyc1")
+	assertMetric(t, mfs, 0, "digitalocean_volume_region_mismatch", "id=v3", "name=spare", "region=fra1")
+	assertMetric(t, mfs, 60*1024*1024*1024, "digitalocean_volumes_size_bytes")
+	assertMetric(t, mfs, 2, "digitalocean_volumes_by_region", "region=nyc1")
+}
+
+func TestVolumeCollectorDropletsDisabled(t *testing.T) {
+	api := newTestAPI(t, volumeFixtures)
+	c := testConfig(api.client(t))
+	c.Disabled = []string{"droplet"}
+	mfs := gather(t, newTestCollector(t, "volume", c))
+
+	assertMetric(t, mfs, 10*1024*1024*1024, "digitalocean_volume_size_bytes", "id=v1", "name=data", "region=nyc1")
+	assertNoMetric(t, mfs, "digitalocean_volume_region_mismatch", "id=v2", "name=logs", "region=nyc1")
+	if n := api.requested("/v2/droplets"); n != 0 {
+		t.Errorf("droplets were listed %d times, want 0", n)
+	}
+}
+
+func TestVolumeCollectorDropletIDs(t *testing.T) {
+	api := newTestAPI(t, volumeFixtures)
+	c := testConfig(api.client(t))
+	c.Cache = NewScrapeCache(c.Client, c.PerPage, []int{1})
+	mfs := gather(t, newTestCollector(t, "volume", c))
+
+	// The region of droplet 2 isn't known as only droplet 1 is collected.
+	assertMetric(t, mfs, 0, "digitalocean_volume_region_mismatch", "id=v1", "name=data", "region=nyc1")
+	assertNoMetric(t, mfs, "digitalocean_volume_region_mismatch", "id=v2", "name=logs", "region=nyc1")
+	assertMetric(t, mfs, 0, "digitalocean_volume_region_mismatch", "id=v3", "name=spare", "region=fra1")
+}
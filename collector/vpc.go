@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterCollector("vpc", func(c Config) prometheus.Collector {
+		return NewVPCCollector(c)
+	})
+}
+
+// apiVPC is a VPC, which the vendored godo doesn't know about.
+type apiVPC struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Region  string `json:"region"`
+	IPRange string `json:"ip_range"`
+	Default bool   `json:"default"`
+}
+
+// VPCCollector collects metrics about the VPCs of the account.
+type VPCCollector struct {
+	logger  log.Logger
+	client  *godo.Client
+	timeout time.Duration
+	perPage int
+	regions []string
+	members bool
+	*lastSuccess
+	pages *pageCounter
+
+	Info    *prometheus.Desc
+	Members *prometheus.Desc
+}
+
+// NewVPCCollector returns a new VPCCollector built from the Config.
+// With VPCIncludeMembers every VPC's members are listed, which is one more API call per VPC.
+func NewVPCCollector(c Config) *VPCCollector {
+	labels := []string{"id", "name", "region"}
+
+	return &VPCCollector{
+		logger:      c.Logger,
+		client:      c.Client,
+		timeout:     c.Timeout,
+		perPage:     c.PerPage,
+		regions:     c.Regions,
+		members:     c.VPCIncludeMembers,
+		lastSuccess: newLastSuccess("vpc"),
+		pages:       newPageCounter("vpc"),
+
+		Info: prometheus.NewDesc(
+			"digitalocean_vpc_info",
+			"A metric with a constant '1' value labeled by the VPC's IP range and whether it's the region's default VPC",
+			append(labels, "ip_range", "default"), nil,
+		),
+		Members: prometheus.NewDesc(
+			"digitalocean_vpc_members",
+			"Number of the VPC's members by their resource type, like droplet or loadbalancer",
+			append(labels, "type"), nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *VPCCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Info
+	ch <- c.Members
+	ch <- c.lastSuccess.desc
+	ch <- c.pages.desc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *VPCCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	ctx, fetched := c.pages.context(ctx)
+	defer c.pages.collect(ch, fetched)
+
+	vpcs, err := listVPCs(ctx, c.client, c.perPage)
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "can't list vpcs",
+			"err", err,
+		)
+		c.lastSuccess.collect(ch, false)
+		return
+	}
+
+	succeeded := true
+	for _, vpc := range vpcs {
+		if !inRegionSlugs(c.regions, vpc.Region) {
+			continue
+		}
+		labels := []string{vpc.ID, vpc.Name, vpc.Region}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.Info,
+			prometheus.GaugeValue,
+			1.0,
+			append(labels, vpc.IPRange, strconv.FormatBool(vpc.Default))...,
+		)
+
+		if !c.members {
+			continue
+		}
+
+		// The members share the collector's timeout with the VPCs.
+		byType, err := countVPCMembers(ctx, c.client, c.perPage, vpc.ID)
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "can't list members of vpc",
+				"vpc", vpc.Name,
+				"err", err,
+			)
+			succeeded = false
+			continue
+		}
+		for memberType, count := range byType {
+			ch <- prometheus.MustNewConstMetric(
+				c.Members,
+				prometheus.GaugeValue,
+				float64(count),
+				append(labels, memberType)...,
+			)
+		}
+	}
+
+	c.lastSuccess.collect(ch, succeeded)
+}
+
+func listVPCs(ctx context.Context, client *godo.Client, perPage int) ([]apiVPC, error) {
+	var vpcs []apiVPC
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			VPCs []apiVPC `json:"vpcs"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/vpcs", opt, &root)
+		vpcs = append(vpcs, root.VPCs...)
+		return resp, err
+	})
+	return vpcs, err
+}
+
+// countVPCMembers returns the number of the VPC's members by their resource type,
+// which is the second part of their URN, like droplet in do:droplet:123.
+func countVPCMembers(ctx context.Context, client *godo.Client, perPage int, id string) (map[string]int, error) {
+	byType := map[string]int{}
+	err := paginate(ctx, perPage, func(opt *godo.ListOptions) (*godo.Response, error) {
+		root := struct {
+			Members []struct {
+				URN string `json:"urn"`
+			} `json:"members"`
+			rawPage
+		}{}
+		resp, err := getPage(ctx, client, "v2/vpcs/"+id+"/members", opt, &root)
+		for _, member := range root.Members {
+			parts := strings.SplitN(member.URN, ":", 3)
+			if len(parts) < 3 {
+				continue
+			}
+			byType[parts[1]]++
+		}
+		return resp, err
+	})
+	return byType, err
+}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"testing"
+)
+
+var vpcFixtures = map[string]string{
+	"/v2/vpcs": `{"vpcs":[
+		{"id":"v1","name":"default-nyc1","region":"nyc1","ip_range":"10.116.0.0/20","default":true},
+		{"id":"v2","name":"backend","region":"fra1","ip_range":"10.10.0.0/24","default":false}
+	],"links":{}}`,
+	"/v2/vpcs/v1/members": `{"members":[
+		{"urn":"do:droplet:1","name":"web"},
+		{"urn":"do:droplet:2","name":"db"},
+		{"urn":"do:loadbalancer:lb1","name":"front"}
+	],"links":{}}`,
+	"/v2/vpcs/v2/members": `{"members":[],"links":{}}`,
+}
+
+func TestVPCCollector(t *testing.T) {
+	api := newTestAPI(t, vpcFixtures)
+	mfs := gather(t, newTestCollector(t, "vpc", testConfig(api.client(t))))
+
+	assertMetric(t, mfs, 1, "digitalocean_vpc_info", "id=v1", "name=default-nyc1", "region=nyc1", "ip_range=10.116.0.0/20", "default=true")
+	assertMetric(t, mfs, 1, "digitalocean_vpc_info", "id=v2", "name=backend", "region=fra1", "ip_range=10.10.0.0/24", "default=false")
+
+	// The members are only listed with VPCIncludeMembers.
+	if n := api.requested("/v2/vpcs/v1/members"); n != 0 {
+		t.Errorf("members were listed %d times, want 0", n)
+	}
+}
+
+func TestVPCCollectorIncludeMembers(t *testing.T) {
+	api := newTestAPI(t, vpcFixtures)
+	c := testConfig(api.client(t))
+	c.VPCIncludeMembers = true
+	mfs := gather(t, newTestCollector(t, "vpc", c))
+
+	nyc1 := []string{"id=v1", "name=default-nyc1", "region=nyc1"}
+	assertMetric(t, mfs, 2, "digitalocean_vpc_members", append(nyc1, "type=droplet")...)
+	assertMetric(t, mfs, 1, "digitalocean_vpc_members", append(nyc1, "type=loadbalancer")...)
+	assertNoMetric(t, mfs, "digitalocean_vpc_members", "id=v2", "name=backend", "region=fra1", "type=droplet")
+}
+
+func TestVPCCollectorRegions(t *testing.T) {
+	api := newTestAPI(t, vpcFixtures)
+	c := testConfig(api.client(t))
+	c.Regions = []string{"fra1"}
+	c.VPCIncludeMembers = true
+	mfs := gather(t, newTestCollector(t, "vpc", c))
+
+	assertNoMetric(t, mfs, "digitalocean_vpc_info", "id=v1", "name=default-nyc1", "region=nyc1", "ip_range=10.116.0.0/20", "default=true")
+	if n := api.requested("/v2/vpcs/v1/members"); n != 0 {
+		t.Errorf("members of a vpc outside the regions were listed %d times, want 0", n)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+// labelGatherer adds constant labels to all metrics of the wrapped Gatherer.
+// A metric that already has one of the labels fails the gathering.
+type labelGatherer struct {
+	gatherer prometheus.Gatherer
+	labels   prometheus.Labels
+}
+
+// Gather implements prometheus.Gatherer.
+func (g labelGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+
+	// The gathered families may be cached, so they are copied instead of labeled.
+	labeled := make([]*dto.MetricFamily, 0, len(mfs))
+	for _, mf := range mfs {
+		metrics := make([]*dto.Metric, 0, len(mf.Metric))
+		for _, m := range mf.Metric {
+			labels := make([]*dto.LabelPair, 0, len(m.Label)+len(g.labels))
+			for _, lp := range m.Label {
+				if _, ok := g.labels[lp.GetName()]; ok {
+					return nil, fmt.Errorf("const label %q is already a label of metric %s", lp.GetName(), mf.GetName())
+				}
+				labels = append(labels, lp)
+			}
+			for name, value := range g.labels {
+				labels = append(labels, &dto.LabelPair{
+					Name:  proto.String(name),
+					Value: proto.String(value),
+				})
+			}
+			sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+			copied := *m
+			copied.Label = labels
+			metrics = append(metrics, &copied)
+		}
+		copied := *mf
+		copied.Metric = metrics
+		labeled = append(labeled, &copied)
+	}
+	return labeled, err
+}
+
+// parseConstLabels parses a comma-separated list of key=value labels.
+func parseConstLabels(s string) (prometheus.Labels, error) {
+	labels := prometheus.Labels{}
+	for _, item := range splitList(s) {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid label %q, must be key=value", item)
+		}
+		name := strings.TrimSpace(kv[0])
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+		if _, ok := labels[name]; ok {
+			return nil, fmt.Errorf("duplicate label name %q", name)
+		}
+		labels[name] = strings.TrimSpace(kv[1])
+	}
+	return labels, nil
+}
+
+// parseLabelKeys parses a comma-separated list of tag keys to be used as label names.
+// The labels the droplets already have can't be used.
+func parseLabelKeys(s string) ([]string, error) {
+	keys := splitList(s)
+	seen := map[string]bool{"id": true, "name": true, "region": true, "account": true}
+	for _, key := range keys {
+		if !model.LabelName(key).IsValid() || strings.HasPrefix(key, model.ReservedLabelPrefix) {
+			return nil, fmt.Errorf("invalid label name %q", key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("label name %q is already used", key)
+		}
+		seen[key] = true
+	}
+	return keys, nil
+}
+
+// legacyNames maps the names of metrics renamed to use base units to their previous names.
+var legacyNames = map[string]string{
+	"digitalocean_start_time_seconds": "digitalocean_start_time",
+}
+
+// legacyNamesGatherer exposes the metrics of the wrapped Gatherer with their legacy names,
+// in addition to their current names.
+type legacyNamesGatherer struct {
+	prometheus.Gatherer
+}
+
+// Gather implements prometheus.Gatherer.
+func (g legacyNamesGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.Gatherer.Gather()
+
+	// The gathered families may be cached, so they are copied instead of renamed.
+	all := make([]*dto.MetricFamily, 0, len(mfs)+len(legacyNames))
+	for _, mf := range mfs {
+		all = append(all, mf)
+		if name, ok := legacyNames[mf.GetName()]; ok {
+			all = append(all, &dto.MetricFamily{
+				Name:   proto.String(name),
+				Help:   mf.Help,
+				Type:   mf.Type,
+				Metric: mf.Metric,
+			})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].GetName() < all[j].GetName() })
+	return all, err
+}
+
+// cachedGatherer serves the metrics of the wrapped Gatherer's last collection,
+// and only collects them again when collect is called.
+type cachedGatherer struct {
+	gatherer prometheus.Gatherer
+
+	mu  sync.Mutex
+	mfs []*dto.MetricFamily
+	err error
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *cachedGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.mfs, g.err
+}
+
+// collect gathers the wrapped Gatherer and keeps the result for Gather.
+func (g *cachedGatherer) collect() error {
+	mfs, err := g.gatherer.Gather()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.mfs, g.err = mfs, err
+	return err
+}
+
+// allowlistGatherer only exposes the metric families of the wrapped Gatherer whose name is allowed.
+type allowlistGatherer struct {
+	gatherer prometheus.Gatherer
+	names    map[string]bool
+
+	// If warn isn't nil, the allowed names without metrics are logged
+	// to the logger once, after the first gathering.
+	logger log.Logger
+	warn   *sync.Once
+}
+
+// Gather implements prometheus.Gatherer.
+func (g allowlistGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+
+	// The gathered families may be cached, so they're filtered into a new slice.
+	allowed := make([]*dto.MetricFamily, 0, len(g.names))
+	for _, mf := range mfs {
+		if g.names[mf.GetName()] {
+			allowed = append(allowed, mf)
+		}
+	}
+
+	if g.warn != nil {
+		g.warn.Do(func() {
+			found := make(map[string]bool, len(allowed))
+			for _, mf := range allowed {
+				found[mf.GetName()] = true
+			}
+			for name := range g.names {
+				if !found[name] {
+					level.Warn(g.logger).Log("msg", "allowlisted metric isn't exposed", "metric", name)
+				}
+			}
+		})
+	}
+
+	return allowed, err
+}
+
+// watchdogGatherer abandons a gathering of the wrapped Gatherer that takes
+// longer than timeout, so that a hanging collector doesn't block all scrapes.
+// An abandoned gathering returns no metrics and no error, so that the scrape
+// still exposes the trip. It keeps running in the background though, and until
+// it returns the wrapped Gatherer isn't gathered again, so that hanging
+// gatherings don't pile up.
+type watchdogGatherer struct {
+	gatherer prometheus.Gatherer
+	timeout  time.Duration
+	logger   log.Logger
+	trips    prometheus.Counter
+
+	mu        sync.Mutex
+	abandoned bool
+}
+
+func newWatchdogGatherer(gatherer prometheus.Gatherer, timeout time.Duration, logger log.Logger) *watchdogGatherer {
+	return &watchdogGatherer{
+		gatherer: gatherer,
+		timeout:  timeout,
+		logger:   logger,
+		trips: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "digitalocean_exporter_watchdog_trips_total",
+			Help: "Total number of collections abandoned because they took longer than the watchdog timeout",
+		}),
+	}
+}
+
+type gatherResult struct {
+	mfs []*dto.MetricFamily
+	err error
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *watchdogGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mu.Lock()
+	abandoned := g.abandoned
+	g.mu.Unlock()
+	if abandoned {
+		level.Warn(g.logger).Log("msg", "abandoned collection is still running, skipping collection")
+		return nil, nil
+	}
+
+	// The channel is buffered, so that an abandoned gathering can still send its result and exit.
+	done := make(chan gatherResult, 1)
+	go func() {
+		mfs, err := g.gatherer.Gather()
+		done <- gatherResult{mfs: mfs, err: err}
+	}()
+
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.mfs, r.err
+	case <-timer.C:
+	}
+
+	g.mu.Lock()
+	g.abandoned = true
+	g.mu.Unlock()
+	go func() {
+		<-done
+		g.mu.Lock()
+		g.abandoned = false
+		g.mu.Unlock()
+		level.Info(g.logger).Log("msg", "abandoned collection finished")
+	}()
+
+	g.trips.Inc()
+	level.Error(g.logger).Log("msg", "collection exceeded the watchdog timeout, abandoning it", "timeout", g.timeout)
+	return nil, nil
+}
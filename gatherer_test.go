@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/metalmatze/digitalocean_exporter/collector"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// families returns the gathered metric families keyed by their name.
+func families(t *testing.T, g prometheus.Gatherer) map[string]*dto.MetricFamily {
+	mfs, err := g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		byName[mf.GetName()] = mf
+	}
+	return byName
+}
+
+func TestLegacyNamesGatherer(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	r := prometheus.NewRegistry()
+	r.MustRegister(collector.NewExporterCollector(log.NewNopLogger(), "1.0", "abc", "today", "go1", "1.1.0", start))
+	cached := &cachedGatherer{gatherer: r}
+	if err := cached.collect(); err != nil {
+		t.Fatal(err)
+	}
+	g := legacyNamesGatherer{cached}
+
+	// Gathering twice makes sure the cached metrics aren't renamed.
+	for i := 0; i < 2; i++ {
+		mfs := families(t, g)
+		for current, legacy := range legacyNames {
+			for _, name := range []string{current, legacy} {
+				mf, ok := mfs[name]
+				if !ok {
+					t.Fatalf("gathering %d: metric %s isn't exposed", i, name)
+				}
+				if got := mf.GetMetric()[0].GetGauge().GetValue(); got != float64(start.Unix()) {
+					t.Errorf("gathering %d: metric %s = %v, want %v", i, name, got, float64(start.Unix()))
+				}
+			}
+		}
+	}
+
+	if _, ok := families(t, r)["digitalocean_start_time"]; ok {
+		t.Error("metric digitalocean_start_time is exposed without legacy names")
+	}
+}
+
+func TestLabelGatherer(t *testing.T) {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge", Help: "A test gauge."}, []string{"region"})
+	gauge.WithLabelValues("nyc1").Set(1)
+	r := prometheus.NewRegistry()
+	r.MustRegister(gauge)
+	cached := &cachedGatherer{gatherer: r}
+	if err := cached.collect(); err != nil {
+		t.Fatal(err)
+	}
+	g := labelGatherer{gatherer: cached, labels: prometheus.Labels{"owner": "infra", "datacenter": "ams3"}}
+
+	// Gathering twice makes sure the labels aren't added to the cached metrics.
+	for i := 0; i < 2; i++ {
+		labels := families(t, g)["test_gauge"].GetMetric()[0].GetLabel()
+		var got []string
+		for _, lp := range labels {
+			got = append(got, lp.GetName()+"="+lp.GetValue())
+		}
+		if want := "datacenter=ams3,owner=infra,region=nyc1"; strings.Join(got, ",") != want {
+			t.Errorf("gathering %d: labels = %s, want %s", i, strings.Join(got, ","), want)
+		}
+	}
+	if n := len(families(t, cached)["test_gauge"].GetMetric()[0].GetLabel()); n != 1 {
+		t.Errorf("cached metric has %d labels, want 1", n)
+	}
+
+	g = labelGatherer{gatherer: cached, labels: prometheus.Labels{"region": "ams3"}}
+	if _, err := g.Gather(); err == nil {
+		t.Error("gathering a metric with a const label it already has succeeded, want an error")
+	}
+}
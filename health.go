@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/metalmatze/digitalocean_exporter/collector"
+)
+
+// healthHandler responds with 503 Service Unavailable if the API can't be
+// reached or rejects the token of any account. The result is kept for ttl,
+// so that frequent probes don't use up the rate limit.
+// With strictFailures greater than 0 it also responds with 503, if any
+// collector failed its last strictFailures collections in a row.
+type healthHandler struct {
+	accounts       []account
+	timeout        time.Duration
+	ttl            time.Duration
+	strictFailures int
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// ServeHTTP implements http.Handler.
+func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := h.check()
+	if err == nil {
+		err = h.checkCollectors()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+// check returns the result of the last check if it's younger than ttl,
+// otherwise it gets every account from the API again.
+func (h *healthHandler) check() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.checked.IsZero() && time.Since(h.checked) < h.ttl {
+		return h.err
+	}
+
+	h.err = nil
+	for _, a := range h.accounts {
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		_, _, err := a.client.Account.Get(ctx)
+		cancel()
+		if err != nil && a.name != "" {
+			err = fmt.Errorf("account %s: %v", a.name, err)
+		}
+		if err != nil {
+			h.err = fmt.Errorf("can't get account: %v", err)
+			break
+		}
+	}
+	h.checked = time.Now()
+	return h.err
+}
+
+// checkCollectors returns an error if any collector failed at least
+// strictFailures collections in a row. It doesn't ask the API, so its
+// result isn't kept.
+func (h *healthHandler) checkCollectors() error {
+	if h.strictFailures <= 0 {
+		return nil
+	}
+
+	for _, a := range h.accounts {
+		names := make([]string, 0, len(a.collectors))
+		for name := range a.collectors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			failures, ok := collector.ConsecutiveFailures(a.collectors[name])
+			if !ok || failures < h.strictFailures {
+				continue
+			}
+			if a.name != "" {
+				name = fmt.Sprintf("%s of account %s", name, a.name)
+			}
+			return fmt.Errorf("collector %s failed its last %d collections", name, failures)
+		}
+	}
+	return nil
+}
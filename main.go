@@ -2,9 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/pprof"
+	"net/url"
 	"os"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	arg "github.com/alexflint/go-arg"
@@ -27,35 +37,176 @@ var (
 	BuildDate string
 	// GoVersion running this binary.
 	GoVersion = runtime.Version()
+	// GodoVersion of the godo client library, taken from its user agent.
+	GodoVersion = strings.TrimPrefix(godo.NewClient(nil).UserAgent, "godo/")
 	// StartTime has the time this was started.
 	StartTime = time.Now()
 )
 
+var inflightScrapes = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "digitalocean_exporter_inflight_scrapes",
+	Help: "Number of scrapes of the metrics paths currently being served",
+})
+
 // Config gets its content from env and passes it on to different packages
 type Config struct {
-	Debug             bool   `arg:"env:DEBUG"`
-	DigitalOceanToken string `arg:"env:DIGITALOCEAN_TOKEN"`
-	HTTPTimeout       int    `arg:"env:HTTP_TIMEOUT"`
-	WebAddr           string `arg:"env:WEB_ADDR"`
-	WebPath           string `arg:"env:WEB_PATH"`
+	Debug                    bool          `arg:"env:DEBUG"`
+	LogSampleRate            int           `arg:"--log.sample-rate,env:LOG_SAMPLE_RATE"`
+	DigitalOceanToken        string        `arg:"env:DIGITALOCEAN_TOKEN"`
+	DigitalOceanTokens       string        `arg:"env:DIGITALOCEAN_TOKENS"`
+	DigitalOceanTokenFile    string        `arg:"--token-file,env:DIGITALOCEAN_TOKEN_FILE"`
+	TokenFileRefresh         time.Duration `arg:"--token-file.refresh,env:DIGITALOCEAN_TOKEN_FILE_REFRESH"`
+	HTTPTimeout              int           `arg:"env:HTTP_TIMEOUT"`
+	APIURL                   string        `arg:"--api.url,env:API_URL"`
+	APIPerPage               int           `arg:"--api.per-page,env:API_PER_PAGE"`
+	APIDialTimeout           time.Duration `arg:"--api.dial-timeout,env:API_DIAL_TIMEOUT"`
+	APIResponseHeaderTimeout time.Duration `arg:"--api.response-header-timeout,env:API_RESPONSE_HEADER_TIMEOUT"`
+	APIETagCache             bool          `arg:"--api.etag-cache,env:API_ETAG_CACHE"`
+	APIETagCacheSize         int           `arg:"--api.etag-cache-size,env:API_ETAG_CACHE_SIZE"`
+	StartupCheck             string        `arg:"--startup-check,env:STARTUP_CHECK"`
+	FailFastOnAuth           bool          `arg:"--collect.fail-fast-on-auth,env:COLLECT_FAIL_FAST_ON_AUTH"`
+	EnableStatusPage         bool          `arg:"--enable-status-page,env:ENABLE_STATUS_PAGE"`
+	RejectEmpty              bool          `arg:"--collect.reject-empty,env:COLLECT_REJECT_EMPTY"`
+	WatchdogTimeout          time.Duration `arg:"--collect.watchdog-timeout,env:COLLECT_WATCHDOG_TIMEOUT"`
+	DurationBuckets          string        `arg:"--metrics.duration-buckets,env:METRICS_DURATION_BUCKETS"`
+	AccountLimitUsage        bool          `arg:"--account.limit-usage,env:ACCOUNT_LIMIT_USAGE"`
+	CollectorsDisabled       string        `arg:"--collectors.disabled,env:COLLECTORS_DISABLED"`
+	DropletIDs               string        `arg:"--droplet.ids,env:DROPLET_IDS"`
+	DropletTagKeys           string        `arg:"--droplet.tag-keys,env:DROPLET_TAG_KEYS"`
+	DropletMaxTagCardinality int           `arg:"--droplet.max-tag-cardinality,env:DROPLET_MAX_TAG_CARDINALITY"`
+	DropletLabelKeys         string        `arg:"--droplet.label-keys,env:DROPLET_LABEL_KEYS"`
+	DropletNameExclude       string        `arg:"--droplet.name-exclude,env:DROPLET_NAME_EXCLUDE"`
+	DropletTagExclude        string        `arg:"--droplet.tag-exclude,env:DROPLET_TAG_EXCLUDE"`
+	DropletRequiredTags      string        `arg:"--droplet.required-tags,env:DROPLET_REQUIRED_TAGS"`
+	DropletInfoOnly          bool          `arg:"--droplet.info-only,env:DROPLET_INFO_ONLY"`
+	DropletRecentWindow      time.Duration `arg:"--droplet.recent-window,env:DROPLET_RECENT_WINDOW"`
+	DropletBackupCounts      bool          `arg:"--droplet.backup-counts,env:DROPLET_BACKUP_COUNTS"`
+	DropletNeighbors         bool          `arg:"--droplet.neighbors,env:DROPLET_NEIGHBORS"`
+	LegacyNames              bool          `arg:"--metrics.legacy-names,env:METRICS_LEGACY_NAMES"`
+	ConstLabels              string        `arg:"--metrics.const-labels,env:METRICS_CONST_LABELS"`
+	Allowlist                string        `arg:"--metrics.allowlist,env:METRICS_ALLOWLIST"`
+	EmitZero                 bool          `arg:"--metrics.emit-zero,env:METRICS_EMIT_ZERO"`
+	DomainIncludeRecords     bool          `arg:"--domain.include-records,env:DOMAIN_INCLUDE_RECORDS"`
+	DatabaseIncludeDetails   bool          `arg:"--database.include-details,env:DATABASE_INCLUDE_DETAILS"`
+	EnableDatabaseMetrics    bool          `arg:"--enable-database-metrics,env:ENABLE_DATABASE_METRICS"`
+	DatabaseMetricsUsername  string        `arg:"--database.metrics-username,env:DATABASE_METRICS_USERNAME"`
+	DatabaseMetricsPassword  string        `arg:"--database.metrics-password,env:DATABASE_METRICS_PASSWORD"`
+	VPCIncludeMembers        bool          `arg:"--vpc.include-members,env:VPC_INCLUDE_MEMBERS"`
+	FloatingIPLastAction     bool          `arg:"--floating-ip.last-action,env:FLOATING_IP_LAST_ACTION"`
+	EnableFunctions          bool          `arg:"--enable-functions,env:ENABLE_FUNCTIONS"`
+	EnableUptime             bool          `arg:"--enable-uptime,env:ENABLE_UPTIME"`
+	EnableBilling            bool          `arg:"--enable-billing,env:ENABLE_BILLING"`
+	BillingSpaces            string        `arg:"--billing.spaces-match,env:BILLING_SPACES_MATCH"`
+	BillingBandwidth         string        `arg:"--billing.bandwidth-match,env:BILLING_BANDWIDTH_MATCH"`
+	Regions                  string        `arg:"--region,env:REGION"`
+	SnapshotPricePerGB       float64       `arg:"--snapshot.price-per-gb,env:SNAPSHOT_PRICE_PER_GB"`
+	WebAddr                  string        `arg:"env:WEB_ADDR"`
+	WebPath                  string        `arg:"--web.telemetry-path,env:WEB_PATH"`
+	WebPathDeprecated        string        `arg:"--webpath" help:"deprecated, use --web.telemetry-path"`
+	WebRoutePrefix           string        `arg:"--web.route-prefix,env:WEB_ROUTE_PREFIX"`
+	WebHealthPath            string        `arg:"--web.health-path,env:WEB_HEALTH_PATH"`
+	HealthCacheTTL           time.Duration `arg:"--health.cache-ttl,env:HEALTH_CACHE_TTL"`
+	HealthStrict             bool          `arg:"--health.strict,env:HEALTH_STRICT"`
+	HealthStrictFailures     int           `arg:"--health.strict-failures,env:HEALTH_STRICT_FAILURES"`
+	WebEnableJSONAPI         bool          `arg:"--web.enable-json-api,env:WEB_ENABLE_JSON_API"`
+	WebEnablePprof           bool          `arg:"--web.enable-pprof,env:WEB_ENABLE_PPROF"`
+	WebCollectOnDemand       bool          `arg:"--web.collect-on-demand,env:WEB_COLLECT_ON_DEMAND"`
+	WebReadTimeout           time.Duration `arg:"--web.read-timeout,env:WEB_READ_TIMEOUT"`
+	WebWriteTimeout          time.Duration `arg:"--web.write-timeout,env:WEB_WRITE_TIMEOUT"`
+	WebTLSCertFile           string        `arg:"--web.tls-cert-file,env:WEB_TLS_CERT_FILE"`
+	WebTLSKeyFile            string        `arg:"--web.tls-key-file,env:WEB_TLS_KEY_FILE"`
+	WebTLSMinVersion         string        `arg:"--web.tls-min-version,env:WEB_TLS_MIN_VERSION"`
 }
 
+// tokenSource is a static DigitalOcean API token.
+type tokenSource string
+
 // Token returns a token or an error.
-func (c Config) Token() (*oauth2.Token, error) {
-	return &oauth2.Token{AccessToken: c.DigitalOceanToken}, nil
+func (t tokenSource) Token() (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: string(t)}, nil
+}
+
+// fileTokenSource reads the DigitalOcean API token from a file.
+// The token expires after refresh, so that the oauth2 client reads the file
+// again and picks up a rotated token without a restart.
+type fileTokenSource struct {
+	path    string
+	refresh time.Duration
+}
+
+// Token returns the token read from the file or an error.
+func (t fileTokenSource) Token() (*oauth2.Token, error) {
+	b, err := ioutil.ReadFile(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read token file: %v", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return nil, fmt.Errorf("token file %s is empty", t.path)
+	}
+	return &oauth2.Token{AccessToken: token, Expiry: time.Now().Add(t.refresh)}, nil
+}
+
+// account is a DigitalOcean account metrics are collected for.
+type account struct {
+	// name is used as account label and left empty with a single token.
+	name     string
+	client   *godo.Client
+	gatherer prometheus.Gatherer
+	// config is what the account's collectors are built from.
+	config collector.Config
+	// collectors are the account's collectors keyed by their name.
+	collectors map[string]prometheus.Collector
 }
 
+// labeled returns g with the account label added, unless the account has no name.
+func (a account) labeled(g prometheus.Gatherer) prometheus.Gatherer {
+	if a.name == "" {
+		return g
+	}
+	return labelGatherer{gatherer: g, labels: prometheus.Labels{"account": a.name}}
+}
+
+// defaultWebPath is the path metrics are served on by default.
+const defaultWebPath = "/metrics"
+
 func main() {
 	_ = godotenv.Load()
 
 	c := Config{
-		HTTPTimeout: 5000,
-		WebPath:     "/metrics",
-		WebAddr:     ":9212",
+		HTTPTimeout:          5000,
+		LogSampleRate:        1,
+		DropletRecentWindow:  15 * time.Minute,
+		APIPerPage:           collector.MaxPerPage,
+		APIETagCacheSize:     1000,
+		APIDialTimeout:       30 * time.Second,
+		SnapshotPricePerGB:   0.06,
+		BillingSpaces:        "(?i)spaces",
+		BillingBandwidth:     "(?i)bandwidth|transfer",
+		WebPath:              defaultWebPath,
+		WebHealthPath:        "/healthz",
+		HealthCacheTTL:       5 * time.Second,
+		HealthStrictFailures: 3,
+		WebAddr:              ":9212",
+		WebReadTimeout:       10 * time.Second,
+		WebWriteTimeout:      30 * time.Second,
+		TokenFileRefresh:     time.Minute,
+		WebTLSMinVersion:     "1.2",
 	}
 	arg.MustParse(&c)
 
-	if c.DigitalOceanToken == "" {
+	var sources []oauth2.TokenSource
+	switch {
+	case c.DigitalOceanTokens != "":
+		for _, token := range splitList(c.DigitalOceanTokens) {
+			sources = append(sources, tokenSource(token))
+		}
+	case c.DigitalOceanTokenFile != "":
+		sources = append(sources, fileTokenSource{path: c.DigitalOceanTokenFile, refresh: c.TokenFileRefresh})
+	case c.DigitalOceanToken != "":
+		sources = append(sources, tokenSource(c.DigitalOceanToken))
+	}
+	if len(sources) == 0 {
 		panic("DigitalOcean Token is required")
 	}
 
@@ -65,50 +216,612 @@ func main() {
 	}
 
 	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	if c.LogSampleRate > 1 {
+		logger = &debugSampler{next: logger, rate: uint64(c.LogSampleRate)}
+	}
 	logger = level.NewFilter(logger, filterOption)
 	logger = log.With(logger,
 		"ts", log.DefaultTimestampUTC,
 		"caller", log.DefaultCaller,
 	)
 
+	if c.LogSampleRate < 1 {
+		level.Error(logger).Log("msg", "log sample rate must be at least 1", "logSampleRate", c.LogSampleRate)
+		os.Exit(1)
+	}
+
 	level.Info(logger).Log(
 		"msg", "starting digitalocean_exporter",
 		"version", Version,
 		"revision", Revision,
 		"buildDate", BuildDate,
 		"goVersion", GoVersion,
+		"godoVersion", GodoVersion,
 	)
 
-	oauthClient := oauth2.NewClient(context.TODO(), c)
-	client := godo.NewClient(oauthClient)
+	// A token file that can't be read makes every request fail.
+	if s, ok := sources[0].(fileTokenSource); ok {
+		if _, err := s.Token(); err != nil {
+			level.Error(logger).Log("msg", "invalid token file", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	// --webpath was the flag before --web.telemetry-path, which wins if both are given.
+	if c.WebPathDeprecated != "" {
+		level.Warn(logger).Log("msg", "flag --webpath is deprecated, use --web.telemetry-path instead")
+		if c.WebPath == defaultWebPath {
+			c.WebPath = c.WebPathDeprecated
+		}
+	}
+
+	if c.StartupCheck != "" && c.StartupCheck != "log" && c.StartupCheck != "strict" {
+		level.Error(logger).Log("msg", "startup check must be one of: log, strict", "startupCheck", c.StartupCheck)
+		os.Exit(1)
+	}
+
+	if c.APIETagCache && c.APIETagCacheSize < 1 {
+		level.Error(logger).Log("msg", "etag cache size must be at least 1", "etagCacheSize", c.APIETagCacheSize)
+		os.Exit(1)
+	}
+
+	if (c.DatabaseMetricsUsername == "") != (c.DatabaseMetricsPassword == "") {
+		level.Error(logger).Log("msg", "database metrics credentials need both a username and a password")
+		os.Exit(1)
+	}
+
+	if c.HealthStrict && c.HealthStrictFailures < 1 {
+		level.Error(logger).Log("msg", "health strict failures must be at least 1", "healthStrictFailures", c.HealthStrictFailures)
+		os.Exit(1)
+	}
+
+	if perPage := c.APIPerPage; perPage < 1 || perPage > collector.MaxPerPage {
+		c.APIPerPage = collector.MaxPerPage
+		if perPage < 1 {
+			c.APIPerPage = 1
+		}
+		level.Warn(logger).Log(
+			"msg", "api per page out of range, clamping",
+			"perPage", perPage,
+			"clamped", c.APIPerPage,
+		)
+	}
 
 	timeout := time.Duration(c.HTTPTimeout) * time.Millisecond
+	regions := splitList(c.Regions)
+	dropletIDs, err := parseIDs(c.DropletIDs)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid droplet ids", "err", err)
+		os.Exit(1)
+	}
+	disabledCollectors := splitList(c.CollectorsDisabled)
+	for _, name := range disabledCollectors {
+		if !isCollector(name) {
+			level.Error(logger).Log("msg", "unknown collector to disable", "collector", name, "collectors", strings.Join(collector.Names(), ","))
+			os.Exit(1)
+		}
+	}
+	dropletTagKeys := splitList(c.DropletTagKeys)
+	dropletLabelKeys, err := parseLabelKeys(c.DropletLabelKeys)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid droplet label keys", "err", err)
+		os.Exit(1)
+	}
+	dropletRequiredTags := splitList(c.DropletRequiredTags)
+
+	dropletFilter, err := parseDropletFilter(c.DropletNameExclude, c.DropletTagExclude)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid droplet filter", "err", err)
+		os.Exit(1)
+	}
+
+	// DigitalOcean may change the wording of the invoice items, so the patterns are configurable.
+	billingSpacesMatch, err := regexp.Compile(c.BillingSpaces)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid billing spaces match", "err", err)
+		os.Exit(1)
+	}
+	billingBandwidthMatch, err := regexp.Compile(c.BillingBandwidth)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid billing bandwidth match", "err", err)
+		os.Exit(1)
+	}
+
+	constLabels, err := parseConstLabels(c.ConstLabels)
+	if _, ok := constLabels["account"]; ok {
+		err = fmt.Errorf("the account label is set by the exporter")
+	}
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid const labels", "err", err)
+		os.Exit(1)
+	}
+
+	allowlist := map[string]bool{}
+	for _, name := range splitList(c.Allowlist) {
+		allowlist[name] = true
+	}
+
+	prometheus.MustRegister(collector.NewExporterCollector(logger, Version, Revision, BuildDate, GoVersion, GodoVersion, StartTime), configInfo(c))
+	buckets := prometheus.DefBuckets
+	if c.DurationBuckets != "" {
+		b, err := parseBuckets(c.DurationBuckets)
+		if err != nil {
+			level.Warn(logger).Log("msg", "can't parse duration buckets, using defaults", "err", err)
+		} else {
+			buckets = b
+		}
+	}
+
+	var roundTripper http.RoundTripper = newHTTPTransport(c.APIDialTimeout, c.APIResponseHeaderTimeout)
+	if c.Debug {
+		roundTripper = loggingTransport{logger: logger, next: roundTripper}
+	}
+	if c.APIETagCache {
+		etag := newETagTransport(roundTripper, c.APIETagCacheSize)
+		prometheus.MustRegister(etag.notModified)
+		roundTripper = etag
+	}
+	transport := newInstrumentedTransport(roundTripper, buckets)
+	prometheus.MustRegister(apiCallsTotal, transport.duration, inflightScrapes)
+	if c.EnableStatusPage {
+		// The status page isn't the API, so its requests aren't counted as API calls.
+		statusClient := &http.Client{Transport: newHTTPTransport(c.APIDialTimeout, c.APIResponseHeaderTimeout), Timeout: timeout}
+		prometheus.MustRegister(collector.NewStatusPageCollector(logger, statusClient, collector.StatusPageURL))
+	}
+
+	var clientOpts []godo.ClientOpt
+	if c.APIURL != "" {
+		// Without a trailing slash a path of the URL would be replaced by the API's paths.
+		clientOpts = append(clientOpts, godo.SetBaseURL(strings.TrimSuffix(c.APIURL, "/")+"/"))
+	}
+
+	// Every account gets its own client and registry, so that a failing
+	// token or an exhausted rate limit only affects that account's metrics.
+	var gatherers prometheus.Gatherers
+	// Every collector also gets a registry of its own, to be scraped on its own.
+	collectorGatherers := map[string]prometheus.Gatherers{}
+	accounts := make([]account, 0, len(sources))
+	// Tokens of the same account would expose the same series twice, which fails every scrape.
+	tokensByName := map[string]int{}
+	for i, source := range sources {
+		// The requests of every account are counted on their own, for its calls per scrape.
+		calls := new(uint64)
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: countingTransport{next: transport, calls: calls}})
+		client, err := godo.New(oauth2.NewClient(ctx, source), clientOpts...)
+		if err != nil {
+			level.Error(logger).Log("msg", "invalid api url", "err", err)
+			os.Exit(1)
+		}
+
+		a := account{client: client}
+		accountLogger := logger
+		if c.DigitalOceanTokens != "" {
+			a.name = accountName(logger, client, timeout, i)
+			if j, ok := tokensByName[a.name]; ok {
+				level.Error(logger).Log(
+					"msg", "tokens belong to the same account, remove one of them from DIGITALOCEAN_TOKENS",
+					"account", a.name,
+					"token", j,
+					"duplicate", i,
+				)
+				os.Exit(1)
+			}
+			tokensByName[a.name] = i
+			accountLogger = log.With(logger, "account", a.name)
+		}
+
+		r := prometheus.NewRegistry()
+		cache := collector.NewScrapeCache(client, c.APIPerPage, dropletIDs)
+		a.config = collector.Config{
+			Logger:                   accountLogger,
+			Client:                   client,
+			Timeout:                  timeout,
+			PerPage:                  c.APIPerPage,
+			Cache:                    cache,
+			Disabled:                 disabledCollectors,
+			RejectEmpty:              c.RejectEmpty,
+			EmitZero:                 c.EmitZero,
+			AccountLimitUsage:        c.AccountLimitUsage,
+			Regions:                  regions,
+			DropletTagKeys:           dropletTagKeys,
+			DropletMaxTagCardinality: c.DropletMaxTagCardinality,
+			DropletLabelKeys:         dropletLabelKeys,
+			DropletFilter:            dropletFilter,
+			DropletRequiredTags:      dropletRequiredTags,
+			DropletInfoOnly:          c.DropletInfoOnly,
+			DropletRecentWindow:      c.DropletRecentWindow,
+			DropletBackupCounts:      c.DropletBackupCounts,
+			DropletNeighbors:         c.DropletNeighbors,
+			DomainIncludeRecords:     c.DomainIncludeRecords,
+			DatabaseIncludeDetails:   c.DatabaseIncludeDetails,
+			DatabaseMetrics:          c.EnableDatabaseMetrics,
+			DatabaseMetricsUsername:  c.DatabaseMetricsUsername,
+			DatabaseMetricsPassword:  c.DatabaseMetricsPassword,
+			VPCIncludeMembers:        c.VPCIncludeMembers,
+			FloatingIPLastAction:     c.FloatingIPLastAction,
+			Functions:                c.EnableFunctions,
+			Uptime:                   c.EnableUptime,
+			Billing:                  c.EnableBilling,
+			BillingSpacesMatch:       billingSpacesMatch,
+			BillingBandwidthMatch:    billingBandwidthMatch,
+			SnapshotPricePerGB:       c.SnapshotPricePerGB,
+		}
+		collectors := collector.Collectors(a.config)
+		for name, col := range collectors {
+			r.MustRegister(col)
+
+			cr := prometheus.NewRegistry()
+			cr.MustRegister(col)
+			collectorGatherers[name] = append(collectorGatherers[name], a.labeled(cache.Gatherer(cr)))
+		}
+
+		g := cache.Gatherer(r)
+		if c.FailFastOnAuth {
+			g = newAuthGatherer(accountLogger, client, timeout, g)
+		}
+		g = scrapeCallsGatherer{Gatherer: g, calls: calls}
+		a.gatherer = a.labeled(g)
+		a.collectors = collectors
+		gatherers = append(gatherers, a.gatherer)
+		accounts = append(accounts, a)
+
+		if c.StartupCheck != "" {
+			if err := startupCheck(accountLogger, a.config); err != nil {
+				if c.StartupCheck == "strict" {
+					level.Error(accountLogger).Log("msg", "startup check failed", "err", err)
+					os.Exit(1)
+				}
+				level.Warn(accountLogger).Log("msg", "startup check failed", "err", err)
+			}
+		}
+	}
+
+	mux := http.NewServeMux()
 
-	prometheus.MustRegister(collector.NewAccountCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewDomainCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewDropletCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewExporterCollector(logger, Version, Revision, BuildDate, GoVersion, StartTime))
-	prometheus.MustRegister(collector.NewFloatingIPCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewImageCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewKeyCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewLoadBalancerCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewSnapshotCollector(logger, client, timeout))
-	prometheus.MustRegister(collector.NewVolumeCollector(logger, client, timeout))
-
-	http.Handle(c.WebPath, promhttp.Handler())
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	// All routes are registered below the prefix, for the mux to redirect within it.
+	routePrefix := strings.TrimSuffix(c.WebRoutePrefix, "/")
+	if routePrefix != "" && !strings.HasPrefix(routePrefix, "/") {
+		routePrefix = "/" + routePrefix
+	}
+	webPath := routePrefix + c.WebPath
+
+	var healthLink string
+	if c.WebHealthPath != "" {
+		healthLink = `<p><a href="` + routePrefix + c.WebHealthPath + `">Health</a></p>`
+		health := &healthHandler{accounts: accounts, timeout: timeout, ttl: c.HealthCacheTTL}
+		if c.HealthStrict {
+			health.strictFailures = c.HealthStrictFailures
+		}
+		mux.Handle(routePrefix+c.WebHealthPath, health)
+	}
+
+	if c.WebEnableJSONAPI {
+		mux.HandleFunc(routePrefix+"/api/resources", func(w http.ResponseWriter, r *http.Request) {
+			// With multiple tokens the resources are keyed by account name.
+			byAccount := make(map[string]*collector.Resources, len(accounts))
+			for _, a := range accounts {
+				resources, err := collector.ListResources(a.config)
+				if err != nil {
+					level.Warn(logger).Log("msg", "can't list resources", "account", a.name, "err", err)
+					http.Error(w, err.Error(), http.StatusBadGateway)
+					return
+				}
+				byAccount[a.name] = resources
+			}
+
+			var v interface{} = byAccount
+			if len(accounts) == 1 && accounts[0].name == "" {
+				v = byAccount[""]
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(v); err != nil {
+				level.Warn(logger).Log("msg", "can't encode resources", "err", err)
+			}
+		})
+	}
+
+	if c.WebEnablePprof {
+		// The index looks up profiles by their path below /debug/pprof/.
+		mux.Handle(routePrefix+"/debug/pprof/", http.StripPrefix(routePrefix, http.HandlerFunc(pprof.Index)))
+		mux.HandleFunc(routePrefix+"/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc(routePrefix+"/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc(routePrefix+"/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc(routePrefix+"/debug/pprof/trace", pprof.Trace)
+	}
+
+	var accountsGatherer prometheus.Gatherer = gatherers
+	if c.WatchdogTimeout > 0 {
+		watchdog := newWatchdogGatherer(accountsGatherer, c.WatchdogTimeout, logger)
+		prometheus.MustRegister(watchdog.trips)
+		accountsGatherer = watchdog
+	}
+	if c.WebCollectOnDemand {
+		cached := &cachedGatherer{gatherer: accountsGatherer}
+		accountsGatherer = cached
+		mux.HandleFunc(routePrefix+"/collect", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err := cached.collect(); err != nil {
+				level.Warn(logger).Log("msg", "can't collect metrics", "err", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		})
+	}
+
+	// The default registry is gathered last, to expose the API calls of this very scrape.
+	var gatherer prometheus.Gatherer = prometheus.Gatherers{accountsGatherer, prometheus.DefaultGatherer}
+	if len(allowlist) > 0 {
+		gatherer = allowlistGatherer{gatherer: gatherer, names: allowlist, logger: logger, warn: &sync.Once{}}
+	}
+	if len(constLabels) > 0 {
+		gatherer = labelGatherer{gatherer: gatherer, labels: constLabels}
+	}
+	if c.LegacyNames {
+		gatherer = legacyNamesGatherer{gatherer}
+	}
+	metrics := countInflight(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+
+	collectorHandlers := make(map[string]http.Handler, len(collectorGatherers))
+	for name, g := range collectorGatherers {
+		var gatherer prometheus.Gatherer = g
+		if len(allowlist) > 0 {
+			gatherer = allowlistGatherer{gatherer: gatherer, names: allowlist}
+		}
+		if len(constLabels) > 0 {
+			gatherer = labelGatherer{gatherer: gatherer, labels: constLabels}
+		}
+		if c.LegacyNames {
+			gatherer = legacyNamesGatherer{gatherer}
+		}
+		collectorHandlers[name] = countInflight(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	}
+	landing := func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte(`<html>
 			<head><title>DigitalOcean Exporter</title></head>
 			<body>
 			<h1>DigitalOcean Exporter</h1>
-			<p><a href="` + c.WebPath + `">Metrics</a></p>
+			<p><a href="` + webPath + `">Metrics</a></p>
+			` + healthLink + `
 			</body>
 			</html>`))
+	}
+
+	// The collectors are served below the metrics path. A metrics path with a trailing
+	// slash is that subtree itself, so it's registered once and serves both.
+	prefix := strings.TrimSuffix(webPath, "/") + "/"
+	if webPath != prefix {
+		mux.Handle(webPath, metrics)
+	}
+	mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == webPath {
+			metrics.ServeHTTP(w, r)
+			return
+		}
+		if h, ok := collectorHandlers[strings.TrimPrefix(r.URL.Path, prefix)]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		// On a metrics path of / the subtree is the landing page's.
+		if prefix == routePrefix+"/" {
+			landing(w, r)
+			return
+		}
+		http.NotFound(w, r)
 	})
+	if prefix != routePrefix+"/" {
+		mux.HandleFunc(routePrefix+"/", landing)
+	}
+
+	server := &http.Server{
+		Addr:         c.WebAddr,
+		Handler:      mux,
+		ReadTimeout:  c.WebReadTimeout,
+		WriteTimeout: c.WebWriteTimeout,
+	}
+	if c.WebTLSCertFile == "" && c.WebTLSKeyFile == "" {
+		level.Info(logger).Log("msg", "listening", "addr", c.WebAddr)
+		err := server.ListenAndServe()
+		level.Error(logger).Log("msg", "http listenandserve error", "err", err)
+		os.Exit(1)
+	}
 
-	level.Info(logger).Log("msg", "listening", "addr", c.WebAddr)
-	if err := http.ListenAndServe(c.WebAddr, nil); err != nil {
+	tlsConfig, err := newTLSConfig(c.WebTLSMinVersion)
+	if err != nil {
+		level.Error(logger).Log("msg", "invalid tls config", "err", err)
+		os.Exit(1)
+	}
+	server.TLSConfig = tlsConfig
+	server.Handler = rejectHTTP10(mux)
+
+	level.Info(logger).Log("msg", "listening", "addr", c.WebAddr, "tls", true, "tlsMinVersion", c.WebTLSMinVersion)
+	if err := server.ListenAndServeTLS(c.WebTLSCertFile, c.WebTLSKeyFile); err != nil {
 		level.Error(logger).Log("msg", "http listenandserve error", "err", err)
 		os.Exit(1)
 	}
 }
+
+// startupCheck lists all resources once and logs how many of each were found.
+func startupCheck(logger log.Logger, c collector.Config) error {
+	r, err := collector.ListResources(c)
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log(
+		"msg", "startup check succeeded",
+		"droplets", len(r.Droplets),
+		"domains", len(r.Domains),
+		"floatingIPs", len(r.FloatingIPs),
+		"images", len(r.Images),
+		"keys", len(r.Keys),
+		"loadBalancers", len(r.LoadBalancers),
+		"snapshots", len(r.Snapshots),
+		"volumes", len(r.Volumes),
+	)
+
+	return nil
+}
+
+// accountName returns the email or uuid of the client's account, used as its account label.
+// If the account can't be read it falls back to the position of the token, so that
+// one bad token doesn't prevent the other accounts from being collected.
+func accountName(logger log.Logger, client *godo.Client, timeout time.Duration, i int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	acc, _, err := client.Account.Get(ctx)
+	if err == nil && acc.Email != "" {
+		return acc.Email
+	}
+	if err == nil && acc.UUID != "" {
+		return acc.UUID
+	}
+
+	name := fmt.Sprintf("token-%d", i)
+	level.Warn(logger).Log(
+		"msg", "can't get account for token, using its position as account label",
+		"account", name,
+		"err", err,
+	)
+	return name
+}
+
+// isCollector reports whether a collector is registered under the name.
+func isCollector(name string) bool {
+	for _, n := range collector.Names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// debugSampler only logs 1 in rate debug lines, so that debug logging stays
+// usable for large accounts. Lines of other levels are always logged.
+type debugSampler struct {
+	next  log.Logger
+	rate  uint64
+	lines uint64
+}
+
+// Log implements log.Logger.
+func (s *debugSampler) Log(keyvals ...interface{}) error {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] != level.Key() || keyvals[i+1] != level.DebugValue() {
+			continue
+		}
+		if atomic.AddUint64(&s.lines, 1)%s.rate != 1 {
+			return nil
+		}
+		break
+	}
+	return s.next.Log(keyvals...)
+}
+
+// countInflight counts the requests being served by next in inflightScrapes.
+func countInflight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inflightScrapes.Inc()
+		defer inflightScrapes.Dec()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseIDs parses a comma-separated list of positive IDs, dropping duplicates.
+func parseIDs(s string) ([]int, error) {
+	var ids []int
+	seen := map[int]bool{}
+	for _, item := range splitList(s) {
+		id, err := strconv.Atoi(item)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid id %q", item)
+		}
+		if !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+	return ids, nil
+}
+
+// parseDropletFilter compiles the regular expressions excluding droplets by name and tag.
+// Empty expressions don't exclude any droplets.
+func parseDropletFilter(nameExclude, tagExclude string) (collector.DropletFilter, error) {
+	var filter collector.DropletFilter
+	if nameExclude != "" {
+		re, err := regexp.Compile(nameExclude)
+		if err != nil {
+			return filter, fmt.Errorf("invalid droplet name exclude: %v", err)
+		}
+		filter.NameExclude = re
+	}
+	if tagExclude != "" {
+		re, err := regexp.Compile(tagExclude)
+		if err != nil {
+			return filter, fmt.Errorf("invalid droplet tag exclude: %v", err)
+		}
+		filter.TagExclude = re
+	}
+	return filter, nil
+}
+
+// splitList splits a comma-separated list and drops empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// configInfo returns an info metric labeled by the non-secret parts of the
+// configuration, which tells what a running exporter actually loaded.
+// The tokens are never included, only where they're read from.
+func configInfo(c Config) prometheus.Gauge {
+	tokenSource := "token"
+	switch {
+	case c.DigitalOceanTokens != "":
+		tokenSource = "tokens"
+	case c.DigitalOceanTokenFile != "":
+		tokenSource = "file"
+	}
+
+	// An API URL may have credentials of a proxy in it.
+	apiURL := c.APIURL
+	if u, err := url.Parse(apiURL); err == nil {
+		u.User = nil
+		apiURL = u.String()
+	} else {
+		apiURL = ""
+	}
+
+	info := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "digitalocean_exporter_config",
+		Help: "A metric with a constant '1' value labeled by the non-secret configuration the exporter was started with.",
+		ConstLabels: prometheus.Labels{
+			"web_addr":          c.WebAddr,
+			"web_path":          c.WebPath,
+			"web_route_prefix":  c.WebRoutePrefix,
+			"http_timeout":      (time.Duration(c.HTTPTimeout) * time.Millisecond).String(),
+			"health_cache_ttl":  c.HealthCacheTTL.String(),
+			"api_url":           apiURL,
+			"api_per_page":      strconv.Itoa(c.APIPerPage),
+			"token_source":      tokenSource,
+			"regions":           c.Regions,
+			"collect_on_demand": strconv.FormatBool(c.WebCollectOnDemand),
+			"fail_fast_on_auth": strconv.FormatBool(c.FailFastOnAuth),
+			"legacy_names":      strconv.FormatBool(c.LegacyNames),
+			"tls":               strconv.FormatBool(c.WebTLSCertFile != ""),
+		},
+	})
+	info.Set(1)
+	return info
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDropletFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		nameExclude string
+		tagExclude  string
+		wantName    bool
+		wantTag     bool
+		wantErr     bool
+	}{
+		{name: "none"},
+		{name: "name", nameExclude: "^ci-", wantName: true},
+		{name: "tag", tagExclude: "^env:dev$", wantTag: true},
+		{name: "both", nameExclude: "^ci-", tagExclude: "^env:dev$", wantName: true, wantTag: true},
+		{name: "invalid name", nameExclude: "ci-(", wantErr: true},
+		{name: "invalid tag", nameExclude: "^ci-", tagExclude: "[env", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := parseDropletFilter(tc.nameExclude, tc.tagExclude)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("want an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.NameExclude != nil; got != tc.wantName {
+				t.Errorf("name exclude set = %v, want %v", got, tc.wantName)
+			}
+			if got := filter.TagExclude != nil; got != tc.wantTag {
+				t.Errorf("tag exclude set = %v, want %v", got, tc.wantTag)
+			}
+		})
+	}
+}
+
+func TestParseLabelKeys(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", s: ""},
+		{name: "keys", s: "env,team", want: []string{"env", "team"}},
+		{name: "spaces and empty items", s: " env ,, team ", want: []string{"env", "team"}},
+		{name: "repeated key", s: "env,env", wantErr: true},
+		{name: "invalid label name", s: "env,cost-center", wantErr: true},
+		{name: "reserved label name", s: "__env", wantErr: true},
+		{name: "droplet label", s: "region", wantErr: true},
+		{name: "account label", s: "account", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseLabelKeys(tc.s)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseLabelKeys(%q) = %q, want an error", tc.s, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("parseLabelKeys(%q) = %q, want %q", tc.s, got, tc.want)
+			}
+		})
+	}
+}
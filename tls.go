@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// tlsVersions maps the accepted --web.tls-min-version values to their tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsCipherSuites are the TLS 1.2 cipher suites offered when serving HTTPS.
+// They are all ECDHE with an AEAD cipher. TLS 1.3 suites aren't configurable and always secure.
+var tlsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// newTLSConfig returns the tls.Config for serving HTTPS with the given minimum version.
+func newTLSConfig(minVersion string) (*tls.Config, error) {
+	v, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("unknown tls version %q, must be one of: 1.0, 1.1, 1.2, 1.3", minVersion)
+	}
+
+	return &tls.Config{
+		MinVersion:   v,
+		CipherSuites: tlsCipherSuites,
+	}, nil
+}
+
+// rejectHTTP10 responds to HTTP/1.0 requests with 505 HTTP Version Not Supported.
+func rejectHTTP10(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.ProtoAtLeast(1, 1) {
+			http.Error(w, "HTTP/1.0 is not supported", http.StatusHTTPVersionNotSupported)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
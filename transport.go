@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	apiCallsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "digitalocean_exporter_api_calls_total",
+		Help: "Total number of requests made to the DigitalOcean API",
+	})
+)
+
+// instrumentedTransport counts and times every request made to the DigitalOcean API.
+type instrumentedTransport struct {
+	next     http.RoundTripper
+	duration prometheus.Histogram
+}
+
+// newInstrumentedTransport returns an instrumentedTransport whose duration histogram uses buckets.
+func newInstrumentedTransport(next http.RoundTripper, buckets []float64) instrumentedTransport {
+	return instrumentedTransport{
+		next: next,
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "digitalocean_exporter_api_request_duration_seconds",
+			Help:    "Duration of requests made to the DigitalOcean API",
+			Buckets: buckets,
+		}),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiCallsTotal.Inc()
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.duration.Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// etagTransport sends the ETag of the last response for a URL with If-None-Match.
+// If the API answers 304 Not Modified, the last response's body is returned again,
+// so unchanged resources aren't transferred again. The requests are still made and
+// count in digitalocean_exporter_api_calls_total.
+// It keeps at most size responses, the least recently used are dropped first.
+type etagTransport struct {
+	next        http.RoundTripper
+	notModified prometheus.Counter
+	size        int
+
+	mu sync.Mutex
+	// responses are keyed by a hash of the Authorization header and the URL,
+	// as accounts share the transport, so that the tokens aren't kept in memory.
+	responses map[string]*list.Element
+	// recent holds the responses' *etagResponse, the most recently used first.
+	recent *list.List
+}
+
+type etagResponse struct {
+	key    string
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func newETagTransport(next http.RoundTripper, size int) *etagTransport {
+	return &etagTransport{
+		next: next,
+		notModified: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "digitalocean_api_not_modified_total",
+			Help: "Total number of requests the DigitalOcean API answered with 304 Not Modified, whose cached response was used",
+		}),
+		size:      size,
+		responses: map[string]*list.Element{},
+		recent:    list.New(),
+	}
+}
+
+// etagKey returns the key of the request's responses.
+func etagKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return hex.EncodeToString(sum[:]) + " " + req.URL.String()
+}
+
+// get returns the response kept for the key and marks it as the most recently used.
+func (t *etagTransport) get(key string) (*etagResponse, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.responses[key]
+	if !ok {
+		return nil, false
+	}
+	t.recent.MoveToFront(e)
+	return e.Value.(*etagResponse), true
+}
+
+// put keeps the response, dropping the least recently used one if there are too many.
+func (t *etagTransport) put(r *etagResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.responses[r.key]; ok {
+		e.Value = r
+		t.recent.MoveToFront(e)
+		return
+	}
+	t.responses[r.key] = t.recent.PushFront(r)
+	for t.recent.Len() > t.size {
+		oldest := t.recent.Back()
+		t.recent.Remove(oldest)
+		delete(t.responses, oldest.Value.(*etagResponse).key)
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := etagKey(req)
+	cached, ok := t.get(key)
+
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		resp.Body.Close()
+		t.notModified.Inc()
+
+		// The headers of the 304 response are newer, like the remaining rate limit.
+		header := cached.header.Clone()
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.put(&etagResponse{key: key, etag: etag, header: resp.Header.Clone(), body: body})
+
+	return resp, nil
+}
+
+// loggingTransport logs every request made to the DigitalOcean API at debug level.
+type loggingTransport struct {
+	logger log.Logger
+	next   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		level.Debug(t.logger).Log(
+			"msg", "api request failed",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"duration", time.Since(start),
+			"err", err,
+		)
+		return resp, err
+	}
+
+	level.Debug(t.logger).Log(
+		"msg", "api request",
+		"method", req.Method,
+		"path", req.URL.Path,
+		"status", resp.StatusCode,
+		"duration", time.Since(start),
+		"requestID", resp.Header.Get("X-Request-Id"),
+	)
+	return resp, err
+}
+
+// newHTTPTransport returns a copy of http.DefaultTransport using the given timeouts.
+// A responseHeaderTimeout of 0 doesn't limit the time waiting for response headers.
+func newHTTPTransport(dialTimeout, responseHeaderTimeout time.Duration) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	t.ResponseHeaderTimeout = responseHeaderTimeout
+	return t
+}
+
+// parseBuckets parses a comma-separated list of ascending histogram buckets in seconds.
+func parseBuckets(s string) ([]float64, error) {
+	var buckets []float64
+	for _, item := range strings.Split(s, ",") {
+		b, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q: %v", item, err)
+		}
+		if len(buckets) > 0 && b <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("buckets must be in ascending order, %v follows %v", b, buckets[len(buckets)-1])
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, nil
+}
+
+// countingTransport counts the requests of the client of an account.
+type countingTransport struct {
+	next  http.RoundTripper
+	calls *uint64
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddUint64(t.calls, 1)
+	return t.next.RoundTrip(req)
+}
+
+// scrapeCallsGatherer adds the number of API calls counted in calls while gathering
+// from the wrapped Gatherer, which is the registry of an account, to its metrics.
+// Requests of the account made meanwhile by other handlers, like of a single
+// collector, are counted as well.
+type scrapeCallsGatherer struct {
+	prometheus.Gatherer
+	calls *uint64
+}
+
+// Gather implements prometheus.Gatherer.
+func (g scrapeCallsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	before := atomic.LoadUint64(g.calls)
+	mfs, err := g.Gatherer.Gather()
+	calls := atomic.LoadUint64(g.calls) - before
+
+	// The calls are only known after gathering, so they can't be a metric of the registry.
+	return append(mfs, &dto.MetricFamily{
+		Name: proto.String("digitalocean_exporter_api_calls_per_scrape"),
+		Help: proto.String("Number of requests made to the DigitalOcean API during the last scrape of the account"),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{
+			Gauge: &dto.Gauge{Value: proto.Float64(float64(calls))},
+		}},
+	}), err
+}
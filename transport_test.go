@@ -0,0 +1,164 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// etagAPI answers every request with an ETag of its URL, and with 304 Not Modified
+// if the request already has it. Every response has the remaining rate limit, which
+// every request counts against.
+type etagAPI struct {
+	requests, notModified int
+}
+
+func (api *etagAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	api.requests++
+	remaining := []string{strconv.Itoa(5000 - api.requests)}
+	etag := `"` + req.URL.Path + `"`
+	if req.Header.Get("If-None-Match") == etag {
+		api.notModified++
+		return &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{"Ratelimit-Remaining": remaining}, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Etag": []string{etag}, "Ratelimit-Remaining": remaining},
+		Body:       ioutil.NopCloser(strings.NewReader(req.URL.Path)),
+		Request:    req,
+	}, nil
+}
+
+func TestETagTransport(t *testing.T) {
+	api := &etagAPI{}
+	transport := newETagTransport(api, 2)
+
+	get := func(token, path string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, "https://api.example.com"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status %d, want 200", path, resp.StatusCode)
+		}
+		return string(body)
+	}
+
+	get("a", "/v2/droplets")
+	if body := get("a", "/v2/droplets"); body != "/v2/droplets" || api.notModified != 1 {
+		t.Errorf("kept response: body %q with %d not modified, want /v2/droplets with 1", body, api.notModified)
+	}
+
+	// Another token doesn't get the response of the first one.
+	get("b", "/v2/droplets")
+	if api.notModified != 1 {
+		t.Errorf("another token's request was answered with not modified")
+	}
+
+	// With a size of 2 the least recently used response of token a is dropped.
+	get("b", "/v2/volumes")
+	get("a", "/v2/droplets")
+	if api.notModified != 1 {
+		t.Errorf("dropped response is still kept")
+	}
+	if n := len(transport.responses); n != 2 || transport.recent.Len() != 2 {
+		t.Errorf("%d responses kept, want 2", n)
+	}
+
+	for key := range transport.responses {
+		if strings.Contains(key, "Bearer") {
+			t.Errorf("key %s contains the token", key)
+		}
+	}
+}
+
+func TestETagTransportHeaders(t *testing.T) {
+	transport := newETagTransport(&etagAPI{}, 2)
+
+	for i, want := range []string{"4999", "4998"} {
+		req, err := http.NewRequest(http.MethodGet, "https://api.example.com/v2/droplets", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		// The kept response has the rate limit of the first request, the 304's is newer.
+		if got := resp.Header.Get("Ratelimit-Remaining"); got != want {
+			t.Errorf("request %d: remaining rate limit %s, want %s", i, got, want)
+		}
+		if got := resp.Header.Get("Etag"); got != `"/v2/droplets"` {
+			t.Errorf("request %d: ETag %s, want the kept one", i, got)
+		}
+	}
+}
+
+// requestGatherer makes requests through the transport when gathered, like the collectors of an account.
+type requestGatherer struct {
+	transport http.RoundTripper
+	requests  int
+}
+
+func (g requestGatherer) Gather() ([]*dto.MetricFamily, error) {
+	for i := 0; i < g.requests; i++ {
+		req, err := http.NewRequest(http.MethodGet, "https://api.example.com/v2/droplets", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := g.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+	}
+	return nil, nil
+}
+
+func TestScrapeCallsGatherer(t *testing.T) {
+	api := &etagAPI{}
+
+	var gatherers prometheus.Gatherers
+	for _, a := range []struct {
+		account
+		requests int
+	}{{account{name: "a"}, 2}, {account{name: "b"}, 3}} {
+		calls := new(uint64)
+		transport := countingTransport{next: api, calls: calls}
+		gatherers = append(gatherers, a.labeled(scrapeCallsGatherer{
+			Gatherer: requestGatherer{transport: transport, requests: a.requests},
+			calls:    calls,
+		}))
+	}
+
+	// Every account only counts its own requests.
+	mf := families(t, gatherers)["digitalocean_exporter_api_calls_per_scrape"]
+	if mf == nil || len(mf.Metric) != 2 {
+		t.Fatalf("calls per scrape = %v, want one per account", mf)
+	}
+	want := map[string]float64{"a": 2, "b": 3}
+	for _, m := range mf.Metric {
+		account := m.Label[0].GetValue()
+		if got := m.GetGauge().GetValue(); got != want[account] {
+			t.Errorf("account %s: %v calls per scrape, want %v", account, got, want[account])
+		}
+	}
+}